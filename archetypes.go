@@ -0,0 +1,106 @@
+package main
+
+import "fmt"
+
+// Archetype is the kind of life a species is. It decides the starting budget, the traits
+// every species of the archetype has and, through the inArchetype predicate, which traits it can pick.
+type Archetype struct {
+	name     string
+	budget   budget
+	implicit []string // names in originTraits
+	popTypes []string
+}
+
+// budget limits the traits a species picks: the trait points to spend, the number of traits it may pick
+// and how many points negative traits may give back. Traits granted by the archetype, origin or civics
+// take no slot. In species rules and templates the budget is added to the budget of the archetype.
+type budget struct {
+	points      int
+	maxTraits   int
+	maxNegative int
+}
+
+var allArchetypes = []Archetype{
+	{name: "Biological", budget: budget{points: 2, maxTraits: 5, maxNegative: 3}, popTypes: []string{"Aquatic", "Mammalian", "Reptilian", "Avian", "Arthropoid", "Molluscoid", "Fungoid", "Plantoid", "Necroid", "Toxoid"}},
+	{name: "Lithoid", budget: budget{points: 2, maxTraits: 5, maxNegative: 3}, implicit: []string{"Lithoid"}, popTypes: []string{"Lithoid"}},
+	{name: "Machine", budget: budget{points: 1, maxTraits: 5, maxNegative: 3}, implicit: []string{"Machine"}, popTypes: []string{"Machine"}},
+	{name: "Robot", budget: budget{points: 0, maxTraits: 4, maxNegative: 2}, implicit: []string{"Mechanical"}, popTypes: []string{"Robot"}},
+}
+
+// Phenotype groups are pop types that share traits no other pop type can pick. Necroids have no traits of their own.
+var (
+	plantPhenotypes  = []string{"Plantoid", "Fungoid"}
+	toxoidPhenotypes = []string{"Toxoid"}
+)
+
+func archetypeOf(popType string) Archetype {
+	for _, archetype := range allArchetypes {
+		if contains(archetype.popTypes, popType) {
+			return archetype
+		}
+	}
+	return allArchetypes[0]
+}
+
+// newSpecies starts a species of the pop type with the points and implicit traits of its archetype.
+func newSpecies(popType string) Species {
+	archetype := archetypeOf(popType)
+	species := Species{popType: popType}.withBudget(archetype.budget)
+	for _, name := range archetype.implicit {
+		species = withTrait(species, name)
+	}
+	return species
+}
+
+func (s Species) withBudget(b budget) Species {
+	s.initialTraitPoints += b.points
+	s.maxTraits += b.maxTraits
+	s.maxNegative += b.maxNegative
+	return s
+}
+
+// pointsLeft is what the species has not spent of its trait points.
+func (s Species) pointsLeft() int {
+	res := s.initialTraitPoints
+	for _, trait := range s.traits {
+		res -= trait.cost
+	}
+	return res
+}
+
+// budgetProblem explains how the traits drawn on top of the base species break its budget, or returns an empty string.
+func budgetProblem(base Species, s Species) string {
+	drawn := s.traits[len(base.traits):]
+	negative := 0
+	for _, trait := range drawn {
+		if trait.cost < 0 {
+			negative -= trait.cost
+		}
+	}
+	switch {
+	case len(drawn) > s.maxTraits:
+		return fmt.Sprintf("%d traits, the limit is %d", len(drawn), s.maxTraits)
+	case negative > s.maxNegative:
+		return fmt.Sprintf("negative traits give %d points, the limit is %d", negative, s.maxNegative)
+	case s.pointsLeft() < 0:
+		return fmt.Sprintf("%d trait points overspent", -s.pointsLeft())
+	case s.pointsLeft() > 0 && !s.allowLeftover:
+		return fmt.Sprintf("%d trait points left", s.pointsLeft())
+	}
+	return ""
+}
+
+// implicitProblem explains why a trait the species was granted does not fit the pop type and its implicit traits,
+// or returns an empty string. Traits only origins and civics hand out are never allowed, so they always fit.
+func implicitProblem(popType string, granted []Trait) string {
+	species := newSpecies(popType)
+	for _, trait := range granted {
+		if trait.isAllowed.kind == "never" {
+			continue
+		}
+		if reason := trait.isAllowed.reason(species); reason != "" {
+			return trait.name + ": " + reason
+		}
+	}
+	return ""
+}
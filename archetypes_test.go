@@ -0,0 +1,49 @@
+package main
+
+import "testing"
+
+func TestBudgetProblem(t *testing.T) {
+	base := Species{popType: "Mammalian", initialTraitPoints: 2, maxTraits: 3, maxNegative: 2, traits: []Trait{{name: "Granted", cost: 0}}}
+	trait := func(cost int) Trait { return Trait{name: "Trait", cost: cost} }
+	tests := []struct {
+		name     string
+		drawn    []Trait
+		leftover bool
+		want     string
+	}{
+		{"points spent", []Trait{trait(1), trait(1)}, false, ""},
+		{"nothing drawn", nil, false, "2 trait points left"},
+		{"points left", []Trait{trait(1)}, false, "1 trait points left"},
+		{"points left allowed", []Trait{trait(1)}, true, ""},
+		{"overspent", []Trait{trait(2), trait(1)}, false, "1 trait points overspent"},
+		{"overspent with leftover allowed", []Trait{trait(3)}, true, "1 trait points overspent"},
+		{"negative traits pay for more", []Trait{trait(2), trait(1), trait(-1)}, false, ""},
+		{"too many traits", []Trait{trait(1), trait(1), trait(1), trait(-1)}, false, "4 traits, the limit is 3"},
+		{"too many negative points", []Trait{trait(3), trait(2), trait(-3)}, false, "negative traits give 3 points, the limit is 2"},
+	}
+	for _, test := range tests {
+		s := base
+		s.allowLeftover = test.leftover
+		s.traits = append(append([]Trait{}, base.traits...), test.drawn...)
+		if got := budgetProblem(base, s); got != test.want {
+			t.Errorf("%s: budgetProblem = %q, want %q", test.name, got, test.want)
+		}
+	}
+}
+
+func TestFillSpeciesGivesUp(t *testing.T) {
+	full := newSpecies("Mammalian").withBudget(budget{points: 2, maxTraits: 5, maxNegative: 3})
+	full.traits = append(full.traits, traitPool(full)...) // nothing left to draw
+	tests := []struct {
+		name    string
+		species Species
+	}{
+		{"budget no traits fit", newSpecies("Mammalian").withBudget(budget{points: 100, maxTraits: 1})},
+		{"empty trait pool", full},
+	}
+	for _, test := range tests {
+		if _, err := fillSpecies(test.species, false, nil, "main species"); err == nil {
+			t.Errorf("%s: fillSpecies found a species", test.name)
+		}
+	}
+}
@@ -0,0 +1,172 @@
+package main
+
+import (
+	"fmt"
+	"strings"
+)
+
+// maxBatchTries bounds the rerolls for a single player, so impossible constraints end in an error instead of a hang.
+const maxBatchTries = 10000
+
+// diversityCandidates is the number of empires rolled per player when the batch has to be diverse.
+const diversityCandidates = 25
+
+// batchOptions configures how the empires of one batch relate to each other.
+type batchOptions struct {
+	unique   uniqueness
+	diverse  bool // pick the candidate least similar to the empires before it
+	traced   bool
+	leftover bool    // allow species that leave trait points unspent
+	fanatic  float64 // chance that a drawn ethic becomes fanatic
+	predict  bool    // predict the civic of the first locked slot
+	order    []string
+	shuffle  bool
+	version  string   // catalogue version, see resolveVersion
+	missing  []string // DLCs no empire may need, see empireDLCs
+	preset   Empire   // components every empire starts with, such as the origin of a draft card
+}
+
+// uniqueness lists what no two empires in one batch may share.
+type uniqueness struct {
+	origin    bool
+	authority bool
+	civics    bool
+}
+
+// generateBatch rolls one empire per player, none of which shares anything that has to be unique with the empires before it.
+func generateBatch(players []string, opts batchOptions) ([]Empire, error) {
+	empires := []Empire{}
+	if opts.fanatic < 0 || opts.fanatic > 1 {
+		return empires, fmt.Errorf("fanatic chance %v is not between 0 and 1", opts.fanatic)
+	}
+	if _, err := catalogueFor(opts.version); err != nil {
+		return empires, err
+	}
+	if err := checkDLCs(opts.missing); err != nil {
+		return empires, err
+	}
+	reject := func(empire Empire) bool {
+		for _, dlc := range empireDLCs(empire) {
+			if contains(opts.missing, dlc) {
+				return true
+			}
+		}
+		return opts.unique.clashes(empire, empires)
+	}
+	for _, player := range players {
+		empire, err := rollCandidate(player, opts, reject)
+		if err != nil {
+			return empires, fmt.Errorf("player %d: %w", len(empires)+1, err)
+		}
+		if opts.diverse {
+			best := maxSimilarity(empire, empires)
+			for i := 1; i < diversityCandidates; i++ {
+				candidate, err := rollCandidate(player, opts, reject)
+				if err != nil {
+					break
+				}
+				if score := maxSimilarity(candidate, empires); score < best {
+					empire, best = candidate, score
+				}
+			}
+		}
+		empires = append(empires, empire)
+	}
+	return empires, nil
+}
+
+// rollCandidate works like fillSpecies, it rerolls until the result is acceptable,
+// here meaning reject returns false, such as for an empire that needs a missing DLC. The trait odds are left to whoever shows the empire, see estimateTraitOdds.
+func rollCandidate(player string, opts batchOptions, reject func(Empire) bool) (Empire, error) {
+	c, err := catalogueFor(opts.version)
+	if err != nil {
+		return Empire{}, err
+	}
+	for try := 0; try < maxBatchTries; try++ {
+		empire := Empire{player: player, allowLeftover: opts.leftover, fanaticChance: opts.fanatic, predictCivic: opts.predict, order: opts.order, shuffleOrder: opts.shuffle, version: c.version}
+		empire.origin, empire.civics = opts.preset.origin, append([]Civic{}, opts.preset.civics...)
+		empire.odds.civics = make([]float64, len(empire.civics)) // preset civics were not drawn
+		if opts.traced {
+			empire.trace = &trace{}
+		}
+		empire, err := fillEmpire(empire)
+		if err != nil {
+			return Empire{}, err
+		}
+		if !reject(empire) {
+			return empire, nil
+		}
+	}
+	return Empire{}, fmt.Errorf("no acceptable empire found after %d tries", maxBatchTries)
+}
+
+func (u uniqueness) clashes(empire Empire, others []Empire) bool {
+	for _, other := range others {
+		if u.origin && other.origin.name == empire.origin.name {
+			return true
+		}
+		if u.authority && other.authority == empire.authority {
+			return true
+		}
+		if u.civics {
+			for _, civic := range empire.civics {
+				for _, otherCivic := range other.civics {
+					if civic.name == otherCivic.name {
+						return true
+					}
+				}
+			}
+		}
+	}
+	return false
+}
+
+// parseUniqueness reads a comma separated list such as "origin,authority,civic".
+func parseUniqueness(s string) (uniqueness, error) {
+	res := uniqueness{}
+	for _, part := range strings.Split(s, ",") {
+		switch strings.TrimSpace(part) {
+		case "":
+		case "origin":
+			res.origin = true
+		case "authority":
+			res.authority = true
+		case "civic", "civics":
+			res.civics = true
+		default:
+			return res, fmt.Errorf("unknown uniqueness constraint %q", part)
+		}
+	}
+	return res, nil
+}
+
+// parseFanaticPercent reads a fanatic chance in percent, as the web app and lobbies take it.
+func parseFanaticPercent(percent int) (float64, error) {
+	if percent < 0 || percent > 100 {
+		return 0, fmt.Errorf("fanatic chance %d%% is not between 0%% and 100%%", percent)
+	}
+	return float64(percent) / 100, nil
+}
+
+// maxPlayers bounds the empires of a lobby, so one host cannot keep the server busy.
+const maxPlayers = 32
+
+// playerList returns the given player names, or count unnamed players when there are none.
+func playerList(names string, count int) []string {
+	res := []string{}
+	for _, name := range strings.Split(names, ",") {
+		if name = strings.TrimSpace(name); name != "" {
+			res = append(res, name)
+		}
+	}
+	if len(res) > 0 {
+		return res
+	}
+	if count <= 0 {
+		count = 3
+	}
+	for i := 0; i < count; i++ {
+		res = append(res, "")
+	}
+	return res
+}
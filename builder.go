@@ -0,0 +1,465 @@
+package main
+
+import (
+	"fmt"
+	"strconv"
+
+	"github.com/maxence-charriere/go-app/v9/pkg/app"
+)
+
+// builder lets the user assemble an empire by hand. Options that would break the
+// rules for the current selection are disabled, with the reason as tooltip.
+type builder struct {
+	app.Compo
+	authority string
+	ethics    [3]string
+	civics    [maxCivicSlots]string // index into latestCatalogue.civics, as civic names are not unique
+	origin    string
+	popType   string
+	traits    []string
+	message   string
+}
+
+func (b *builder) Render() app.UI {
+	empire := b.empire()
+	return app.Div().Body(
+		app.A().Href("/").Text("Random empires"),
+		app.Br(),
+		app.Button().Text("Randomise the remaining fields").OnClick(b.randomise),
+		app.Button().Text("Clear").OnClick(b.clear),
+		app.If(b.message != "", app.Span().Text(b.message)),
+		app.Br(),
+		app.Label().Text("Ethics:"),
+		app.Range(b.ethics[:]).Slice(func(i int) app.UI {
+			return b.ethicSelect(i)
+		}),
+		app.Br(),
+		app.Label().Text("Authority:").For("authority"),
+		app.Select().ID("authority").OnChange(b.ValueTo(&b.authority)).Body(
+			app.Option().Value("").Text("-").Selected(b.authority == ""),
+			app.Range(latestCatalogue.authorities).Slice(func(i int) app.UI {
+				name := latestCatalogue.authorities[i].name
+				candidate := empire
+				candidate.authority = name
+				return option(name, name, name == b.authority, empireProblem(candidate))
+			}),
+		),
+		app.Br(),
+		app.If(b.authority != "", app.Span().Text("Government: "+governmentName(empire))),
+		app.Br(),
+		app.Label().Text("Civics:"),
+		app.Range(b.civics[:b.freeSlots()]).Slice(func(i int) app.UI {
+			return b.civicSelect(i)
+		}),
+		app.Span().Text(civicSlotsText(empire)),
+		app.Br(),
+		app.Label().Text("Origin:").For("origin"),
+		app.Select().ID("origin").OnChange(b.ValueTo(&b.origin)).Body(
+			app.Option().Value("").Text("-").Selected(b.origin == ""),
+			app.Range(latestCatalogue.origins).Slice(func(i int) app.UI {
+				name := latestCatalogue.origins[i].name
+				candidate := empire
+				candidate.origin = latestCatalogue.origins[i]
+				return option(name, name, name == b.origin, empireProblem(candidate))
+			}),
+		),
+		app.Br(),
+		app.Label().Text("Pop type:").For("popType"),
+		app.Select().ID("popType").OnChange(b.ValueTo(&b.popType)).Body(
+			app.Option().Value("").Text("-").Selected(b.popType == ""),
+			app.Range(builderPopTypes()).Slice(func(i int) app.UI {
+				name := builderPopTypes()[i]
+				return option(name, name, name == b.popType, popTypeProblem(empire, name))
+			}),
+		),
+		app.Br(),
+		app.Span().Text(fmt.Sprintf("Trait points: %d of %d left, trait slots: %d of %d left",
+			b.traitPoints(), empire.mainSpecies.initialTraitPoints, b.slotsLeft(), budgetedSpecies(empire, b.popType).maxTraits)),
+		app.Ul().Class("traits").Body(app.Range(b.traitOptions()).Slice(func(i int) app.UI {
+			trait := b.traitOptions()[i]
+			selected := contains(b.traits, trait.name)
+			reason := ""
+			if !selected {
+				reason = b.traitProblem(trait)
+			}
+			return app.Li().Title(reason).Body(
+				app.Input().Type("checkbox").ID("trait"+strconv.Itoa(i)).Checked(selected).Disabled(reason != "").OnChange(b.toggleTrait(trait.name)),
+				app.Label().For("trait"+strconv.Itoa(i)).Text(fmt.Sprintf("%s (%d)", trait.name, trait.cost)),
+			)
+		})),
+	)
+}
+
+func option(value string, text string, selected bool, problem string) app.UI {
+	return app.Option().Value(value).Text(text).Selected(selected).Disabled(problem != "" && !selected).Title(problem)
+}
+
+func (b *builder) ethicSelect(slot int) app.UI {
+	others := b.empire()
+	others.ethics = []Ethic{}
+	for i, name := range b.ethics {
+		if i != slot && name != "" {
+			others.ethics = append(others.ethics, ethicByName(name))
+		}
+	}
+	names := ethicOptions()
+	return app.Select().OnChange(b.ValueTo(&b.ethics[slot])).Body(
+		app.Option().Value("").Text("-").Selected(b.ethics[slot] == ""),
+		app.Range(names).Slice(func(i int) app.UI {
+			candidate := others
+			candidate.ethics = append(append([]Ethic{}, others.ethics...), ethicByName(names[i]))
+			return option(names[i], names[i], names[i] == b.ethics[slot], empireProblem(candidate))
+		}),
+	)
+}
+
+func (b *builder) civicSelect(slot int) app.UI {
+	others := b.empire()
+	others.civics = []Civic{}
+	for i, index := range b.civics[:b.freeSlots()] {
+		if i != slot && index != "" {
+			others.civics = append(others.civics, civicByIndex(index))
+		}
+	}
+	return app.Select().OnChange(b.ValueTo(&b.civics[slot])).Body(
+		app.Option().Value("").Text("-").Selected(b.civics[slot] == ""),
+		app.Range(latestCatalogue.civics).Slice(func(i int) app.UI {
+			candidate := others
+			candidate.civics = append(append([]Civic{}, others.civics...), latestCatalogue.civics[i])
+			index := strconv.Itoa(i)
+			return option(index, latestCatalogue.civics[i].name, index == b.civics[slot], empireProblem(candidate))
+		}),
+	)
+}
+
+func (b *builder) toggleTrait(name string) app.EventHandler {
+	return func(ctx app.Context, e app.Event) {
+		for i, trait := range b.traits {
+			if trait == name {
+				b.traits = append(b.traits[:i], b.traits[i+1:]...)
+				return
+			}
+		}
+		b.traits = append(b.traits, name)
+	}
+}
+
+func (b *builder) clear(ctx app.Context, e app.Event) {
+	*b = builder{Compo: b.Compo}
+}
+
+// empire converts the current selection into an Empire, leaving unselected fields empty.
+func (b *builder) empire() Empire {
+	empire := Empire{authority: b.authority, fanaticChance: defaultFanaticChance}
+	for _, name := range b.ethics {
+		if name != "" {
+			empire.ethics = append(empire.ethics, ethicByName(name))
+		}
+	}
+	for _, origin := range latestCatalogue.origins {
+		if origin.name == b.origin {
+			empire.origin = origin
+		}
+	}
+	for _, index := range b.civics[:b.freeSlots()] {
+		if index != "" {
+			empire.civics = append(empire.civics, civicByIndex(index))
+		}
+	}
+	empire.mainSpecies = mainSpeciesBase(empire, b.popType)
+	for _, name := range b.traits {
+		for _, trait := range traitPool(empire.mainSpecies) {
+			if trait.name == name && !contains(traitNames(empire.mainSpecies.traits), name) {
+				empire.mainSpecies.traits = append(empire.mainSpecies.traits, trait)
+			}
+		}
+	}
+	return empire
+}
+
+// freeSlots counts the civic selects to show. Slot rules only test the authority and origin, so the civics are left out.
+func (b *builder) freeSlots() int {
+	free, _ := civicSlots(Empire{authority: b.authority, origin: latestCatalogue.origin(b.origin)})
+	return free
+}
+
+func (b *builder) traitOptions() []Trait {
+	return traitPool(b.empire().mainSpecies)
+}
+
+func (b *builder) traitPoints() int {
+	return b.empire().mainSpecies.pointsLeft()
+}
+
+// slotsLeft counts the traits the user may still pick. Traits the species starts with take no slot.
+func (b *builder) slotsLeft() int {
+	empire := b.empire()
+	base := mainSpeciesBase(empire, b.popType)
+	return base.maxTraits - (len(empire.mainSpecies.traits) - len(base.traits))
+}
+
+func (b *builder) traitProblem(trait Trait) string {
+	empire := b.empire()
+	species := empire.mainSpecies
+	if trait.nonGestalt && b.authority == "Hive Mind" {
+		return "not available to gestalt empires"
+	}
+	if trait.cost > b.traitPoints() {
+		return "not enough trait points"
+	}
+	if b.slotsLeft() <= 0 {
+		return "no trait slots left"
+	}
+	if trait.cost < 0 {
+		candidate := species
+		candidate.traits = append(append([]Trait{}, species.traits...), trait)
+		candidate.allowLeftover = true
+		if problem := budgetProblem(mainSpeciesBase(empire, b.popType), candidate); problem != "" {
+			return problem
+		}
+	}
+	return trait.isAllowed.reason(species)
+}
+
+// randomise fills every field that is still empty, retrying until the result passes all rules.
+func (b *builder) randomise(ctx app.Context, e app.Event) {
+	for try := 0; try < 1000; try++ {
+		empire, ok := completeEmpire(b.empire())
+		if !ok || empireProblem(empire) != "" {
+			continue
+		}
+		b.authority = empire.authority
+		for i := range b.ethics {
+			b.ethics[i] = ""
+			if i < len(empire.ethics) {
+				b.ethics[i] = empire.ethics[i].name
+			}
+		}
+		for i := range b.civics {
+			b.civics[i] = civicIndex(empire, i)
+		}
+		b.origin = empire.origin.name
+		b.popType = empire.mainSpecies.popType
+		b.traits = []string{}
+		for _, trait := range empire.mainSpecies.traits {
+			b.traits = append(b.traits, trait.name)
+		}
+		b.message = ""
+		return
+	}
+	b.message = "No valid empire found for this selection"
+}
+
+// completeEmpire runs the generation steps for the fields that are still empty.
+func completeEmpire(empire Empire) (Empire, bool) {
+	if len(empire.ethics) == 0 {
+		empire = chooseEthic(empire)
+	}
+	for ethicPoints(empire.ethics) < maxEthicPoints {
+		ethicList := getEthicList(empire, nil)
+		if len(ethicList) == 0 {
+			return empire, false
+		}
+		empire.ethics = append(empire.ethics, ethicList[r.Intn(len(ethicList))])
+	}
+	if empire.authority == "" {
+		result := []Authority{}
+		for _, auth := range empire.catalogue().authorities {
+			if auth.isAllowed.test(empire) {
+				result = append(result, auth)
+			}
+		}
+		if len(result) == 0 {
+			return empire, false
+		}
+		empire.authority = result[r.Intn(len(result))].name
+	}
+	for free, _ := civicSlots(empire); len(empire.civics) < free; free, _ = civicSlots(empire) {
+		if len(getCivicList(empire, nil)) == 0 {
+			return empire, false
+		}
+		empire = chooseCivic(empire)
+	}
+	if empire.origin.name == "" {
+		result := []Origin{}
+		for _, origin := range empire.catalogue().origins {
+			if origin.isAllowed.test(empire) {
+				result = append(result, origin)
+			}
+		}
+		if len(result) == 0 {
+			return empire, false
+		}
+		empire.origin = result[r.Intn(len(result))]
+	}
+	empire = fillCivicSlots(empire) // the origin may open more slots
+	return completeSpecies(empire)
+}
+
+// completeSpecies rolls the main species around the pop type and traits already picked. Like generateSpecies it
+// starts from the traits the origin, civics and authority grant, the picked traits then count as drawn ones.
+func completeSpecies(empire Empire) (Empire, bool) {
+	popType, picked := empire.mainSpecies.popType, empire.mainSpecies.traits
+	empire, err := generateSpecies(empire)
+	if err != nil {
+		return empire, false
+	}
+	if popType == "" && len(picked) == 0 {
+		return empire, true
+	}
+	if popType == "" {
+		popType = empire.mainSpecies.popType
+	}
+	if popTypeProblem(empire, popType) != "" {
+		return empire, false
+	}
+	base := mainSpeciesBase(empire, popType)
+	species := base
+	for _, trait := range picked {
+		if contains(traitNames(species.traits), trait.name) {
+			continue
+		}
+		if trait.isAllowed.reason(species) != "" {
+			return empire, false
+		}
+		species.traits = append(species.traits, trait)
+	}
+	counts := []int{}
+	for _, count := range traitCounts(base) {
+		if count >= len(species.traits)-len(base.traits) {
+			counts = append(counts, count)
+		}
+	}
+	if len(counts) == 0 {
+		empire.mainSpecies = species
+		return empire, budgetProblem(base, species) == ""
+	}
+	for try := 0; try < maxBatchTries; try++ {
+		if result, ok := finishTraits(species, base, counts[r.Intn(len(counts))], empire.authority == "Hive Mind", r); ok {
+			empire.mainSpecies = result
+			return empire, true
+		}
+	}
+	return empire, false
+}
+
+// empireProblem returns the first rule the empire breaks, or an empty string if it is valid so far.
+func empireProblem(empire Empire) string {
+	if ethicPoints(empire.ethics) > maxEthicPoints {
+		return "not enough ethic points"
+	}
+	for i, ethic := range empire.ethics {
+		others := empire
+		others.ethics = append(append([]Ethic{}, empire.ethics[:i]...), empire.ethics[i+1:]...)
+		if reason := ethicAxisProblem(ethic, others.ethics); reason != "" {
+			return ethic.name + ": " + reason
+		}
+		if reason := ethic.isAllowed.reason(others); reason != "" {
+			return ethic.name + ": " + reason
+		}
+	}
+	if empire.authority != "" {
+		c := empire.catalogue()
+		if !c.has("authority", empire.authority) {
+			return empire.authority + ": not in game version " + c.version
+		}
+		for _, auth := range c.authorities {
+			if auth.name == empire.authority {
+				if reason := auth.isAllowed.reason(empire); reason != "" {
+					return auth.name + ": " + reason
+				}
+			}
+		}
+	}
+	for i, civic := range empire.civics {
+		others := empire
+		others.civics = append(append([]Civic{}, empire.civics[:i]...), empire.civics[i+1:]...)
+		for _, other := range others.civics {
+			if other.name == civic.name {
+				return civic.name + ": already chosen"
+			}
+		}
+		if reason := civic.isAllowed.reason(others); reason != "" {
+			return civic.name + ": " + reason
+		}
+	}
+	if empire.origin.name != "" {
+		if reason := empire.origin.isAllowed.reason(empire); reason != "" {
+			return empire.origin.name + ": " + reason
+		}
+	}
+	if reason := popTypeProblem(empire, empire.mainSpecies.popType); reason != "" {
+		return empire.mainSpecies.popType + ": " + reason
+	}
+	return ""
+}
+
+func ethicPoints(ethics []Ethic) int {
+	res := 0
+	for _, ethic := range ethics {
+		res += ethic.points
+	}
+	return res
+}
+
+// ethicOptions lists every ethic once, including the fanatic forms.
+func ethicOptions() []string {
+	res := []string{}
+	for _, ethic := range allEthics {
+		if !contains(res, ethic.name) {
+			res = append(res, ethic.name)
+		}
+	}
+	return res
+}
+
+func ethicByName(name string) Ethic {
+	for _, ethic := range allEthics {
+		if ethic.name == name {
+			return ethic
+		}
+	}
+	return Ethic{name: name, isAllowed: always}
+}
+
+func civicByIndex(index string) Civic {
+	i, err := strconv.Atoi(index)
+	if err != nil || i < 0 || i >= len(latestCatalogue.civics) {
+		return Civic{}
+	}
+	return latestCatalogue.civics[i]
+}
+
+// civicIndex finds the catalogue entry of a chosen civic, picking the variant that is allowed for the empire.
+func civicIndex(empire Empire, slot int) string {
+	if slot >= len(empire.civics) {
+		return ""
+	}
+	others := empire
+	others.civics = append(append([]Civic{}, empire.civics[:slot]...), empire.civics[slot+1:]...)
+	for i, civic := range latestCatalogue.civics {
+		if civic.name == empire.civics[slot].name && civic.isAllowed.test(others) {
+			return strconv.Itoa(i)
+		}
+	}
+	return ""
+}
+
+// popTypeProblem explains why the species rules of the empire do not allow the pop type, or returns an empty string.
+func popTypeProblem(empire Empire, popType string) string {
+	if popType == "" {
+		return ""
+	}
+	if !contains(mainPopTypes(empire), popType) {
+		return "not allowed by the authority, origin and civics"
+	}
+	return implicitProblem(popType, grantedTraits(speciesRulesOf(empire)))
+}
+
+func builderPopTypes() []string {
+	return append([]string{"Machine"}, latestCatalogue.popTypes...)
+}
+
+func initialTraitPoints(popType string) int {
+	return newSpecies(popType).initialTraitPoints
+}
@@ -0,0 +1,365 @@
+package main
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/maxence-charriere/go-app/v9/pkg/app"
+)
+
+// pageKinds are the kinds of items that get a catalogue page, in the order they are listed.
+var pageKinds = []string{"authority", "ethic", "civic", "origin", "trait"}
+
+// catalogueRef names one item of the catalogue. Items that share a name, such as Organic Reprocessing
+// for machines and hives, are one item, see catalogueEntry.
+type catalogueRef struct {
+	kind string
+	name string
+}
+
+// pageEntries are the entries of the latest catalogue that get a page.
+func pageEntries() []catalogueEntry {
+	res := []catalogueEntry{}
+	for _, entry := range catalogueEntries(latestCatalogue) {
+		if contains(pageKinds, entry.kind) {
+			res = append(res, entry)
+		}
+	}
+	return res
+}
+
+// itemPath is the page of an item, such as /catalogue/civic/free-haven.
+func itemPath(ref catalogueRef) string {
+	return "/catalogue/" + ref.kind + "/" + slug(ref.name)
+}
+
+func slug(name string) string {
+	res := []rune{}
+	for _, c := range strings.ToLower(name) {
+		switch {
+		case c >= 'a' && c <= 'z', c >= '0' && c <= '9':
+			res = append(res, c)
+		case len(res) > 0 && res[len(res)-1] != '-':
+			res = append(res, '-')
+		}
+	}
+	return strings.TrimSuffix(string(res), "-")
+}
+
+// cataloguePages lists the paths of all item pages, so the static website includes them.
+func cataloguePages() []string {
+	res := []string{}
+	for _, entry := range pageEntries() {
+		res = append(res, itemPath(catalogueRef{kind: entry.kind, name: entry.name}))
+	}
+	return res
+}
+
+// entryByPath finds the item of a page path.
+func entryByPath(path string) (catalogueEntry, bool) {
+	for _, entry := range pageEntries() {
+		if strings.HasSuffix(path, itemPath(catalogueRef{kind: entry.kind, name: entry.name})) {
+			return entry, true
+		}
+	}
+	return catalogueEntry{}, false
+}
+
+// withItem adds every variant of the item to the empire, one empire per variant.
+func withItem(empire Empire, ref catalogueRef) []Empire {
+	res := []Empire{}
+	switch ref.kind {
+	case "authority":
+		empire.authority = ref.name
+		res = append(res, empire)
+	case "ethic":
+		empire.ethics = append(append([]Ethic{}, empire.ethics...), ethicByName(ref.name))
+		res = append(res, empire)
+	case "civic":
+		for _, civic := range empire.catalogue().civics {
+			if civic.name == ref.name {
+				variant := empire
+				variant.civics = append(append([]Civic{}, empire.civics...), civic)
+				res = append(res, variant)
+			}
+		}
+	case "origin":
+		empire.origin = empire.catalogue().origin(ref.name)
+		res = append(res, empire)
+	}
+	return res
+}
+
+// incompatibility explains why no empire can have both items, or returns an empty string when one can.
+// The empire needs an authority and ethics that allow both, other civics and the origin stay open like
+// during generation. Traits are only compared with traits, their rules about the rest of the empire
+// go through the species.
+func incompatibility(a catalogueRef, b catalogueRef) string {
+	if a.kind == "trait" || b.kind == "trait" {
+		return traitClash(a.name, b.name)
+	}
+	if a.kind == b.kind && (a.kind == "authority" || a.kind == "origin") {
+		return "an empire has one " + a.kind
+	}
+	res := ""
+	for _, first := range withItem(Empire{}, a) {
+		for _, empire := range withItem(first, b) {
+			problem := itemProblem(empire)
+			if problem == "" {
+				if completes(empire) {
+					return ""
+				}
+				problem = "no authority and ethics allow both"
+			}
+			if res == "" {
+				res = problem
+			}
+		}
+	}
+	return res
+}
+
+// completes tells whether some authority and set of ethics, including those the empire has, satisfy
+// every rule of the empire.
+func completes(empire Empire) bool {
+	authorities := []string{empire.authority}
+	if empire.authority == "" {
+		authorities = []string{}
+		for _, auth := range empire.catalogue().authorities {
+			authorities = append(authorities, auth.name)
+		}
+	}
+	for _, ethics := range ethicSets() {
+		if !containsEthics(ethics, empire.ethics) {
+			continue
+		}
+		for _, auth := range authorities {
+			candidate := empire
+			candidate.authority, candidate.ethics = auth, ethics
+			if partialProblem(candidate) == "" {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+func containsEthics(set []Ethic, ethics []Ethic) bool {
+	for _, ethic := range ethics {
+		if !contains(ethicNames(set), ethic.name) {
+			return false
+		}
+	}
+	return true
+}
+
+var allEthicSets [][]Ethic
+
+// ethicSets lists every legal choice of ethics once: maxEthicPoints worth of ethics on different axes.
+func ethicSets() [][]Ethic {
+	if allEthicSets != nil {
+		return allEthicSets
+	}
+	names := ethicOptions()
+	var extend func(set []Ethic, from int)
+	extend = func(set []Ethic, from int) {
+		if ethicPoints(set) == maxEthicPoints {
+			allEthicSets = append(allEthicSets, set)
+			return
+		}
+		for i := from; i < len(names); i++ {
+			ethic := ethicByName(names[i])
+			if ethicPoints(set)+ethic.points <= maxEthicPoints && ethicAxisProblem(ethic, set) == "" {
+				extend(append(append([]Ethic{}, set...), ethic), i+1)
+			}
+		}
+	}
+	extend(nil, 0)
+	return allEthicSets
+}
+
+// itemProblem is partialProblem with the ethic rules that chooseEthic applies while drawing.
+func itemProblem(empire Empire) string {
+	if ethicPoints(empire.ethics) > maxEthicPoints {
+		return fmt.Sprintf("more than %d ethic points", maxEthicPoints)
+	}
+	for i, ethic := range empire.ethics {
+		others := append(append([]Ethic{}, empire.ethics[:i]...), empire.ethics[i+1:]...)
+		if reason := ethicAxisProblem(ethic, others); reason != "" {
+			return ethic.name + ": " + reason
+		}
+	}
+	return partialProblem(empire)
+}
+
+// traitClash explains why no species can have both traits, or returns an empty string when one can.
+// Exclusions are named, traits that only share no pop type get a general reason.
+func traitClash(a string, b string) string {
+	first, second := latestCatalogue.trait(a), latestCatalogue.trait(b)
+	for _, archetype := range latestCatalogue.archetypes {
+		for _, popType := range archetype.popTypes {
+			withSecond := Species{popType: popType, traits: []Trait{second}}
+			withFirst := Species{popType: popType, traits: []Trait{first}}
+			if first.isAllowed.test(withSecond) && second.isAllowed.test(withFirst) {
+				return ""
+			}
+			if reason := first.isAllowed.reason(withSecond); strings.HasPrefix(reason, "excluded by") {
+				return a + ": " + reason
+			}
+			if reason := second.isAllowed.reason(withFirst); strings.HasPrefix(reason, "excluded by") {
+				return b + ": " + reason
+			}
+		}
+	}
+	return "no pop type can have both"
+}
+
+// catalogueView lists every item of the catalogue with its rules, and filters them by name, kind and DLC.
+type catalogueView struct {
+	app.Compo
+	search string
+	kind   string
+	dlc    string
+}
+
+func (v *catalogueView) Render() app.UI {
+	entries := []catalogueEntry{}
+	for _, entry := range pageEntries() {
+		if v.matches(entry) {
+			entries = append(entries, entry)
+		}
+	}
+	return app.Div().Body(
+		app.A().Href("/").Text("Random empires"),
+		app.Text(" "),
+		app.A().Href("/matrix").Text("Compatibility matrix"),
+		app.H3().Text("Catalogue "+latestCatalogue.version),
+		app.Label().Text("Search:").For("search"),
+		app.Input().ID("search").Value(v.search).OnInput(v.ValueTo(&v.search)),
+		app.Label().Text("Kind:").For("kind"),
+		app.Select().ID("kind").OnChange(v.ValueTo(&v.kind)).Body(
+			app.Option().Value("").Text("all"),
+			app.Range(pageKinds).Slice(func(i int) app.UI {
+				return app.Option().Value(pageKinds[i]).Text(pageKinds[i]).Selected(pageKinds[i] == v.kind)
+			}),
+		),
+		app.Label().Text("DLC:").For("dlc"),
+		app.Select().ID("dlc").OnChange(v.ValueTo(&v.dlc)).Body(
+			app.Option().Value("").Text("any"),
+			app.Option().Value("-").Text("none recorded").Selected(v.dlc == "-"),
+			app.Range(dlcNames()).Slice(func(i int) app.UI {
+				return app.Option().Value(dlcNames()[i]).Text(dlcNames()[i]).Selected(dlcNames()[i] == v.dlc)
+			}),
+		),
+		app.Table().Class("catalogue").Body(
+			app.Tr().Body(app.Th().Text("Name"), app.Th().Text("Kind"), app.Th().Text("Rules"), app.Th().Text("DLC"), app.Th().Text("Cost")),
+			app.Range(entries).Slice(func(i int) app.UI {
+				entry := entries[i]
+				return app.Tr().Body(
+					app.Td().Body(app.A().Href(itemPath(catalogueRef{kind: entry.kind, name: entry.name})).Text(entry.name)),
+					app.Td().Text(entry.kind),
+					app.Td().Text(strings.Join(entry.variants, " | ")),
+					app.Td().Text(dlcOf(entry.name)),
+					app.Td().Text(traitCostText(entry)),
+				)
+			}),
+		),
+	)
+}
+
+// matches tells whether the entry passes the filters. The search looks at names and rules.
+func (v *catalogueView) matches(entry catalogueEntry) bool {
+	search := strings.ToLower(strings.TrimSpace(v.search))
+	switch {
+	case v.kind != "" && entry.kind != v.kind:
+		return false
+	case v.dlc == "-" && dlcOf(entry.name) != "", v.dlc != "" && v.dlc != "-" && dlcOf(entry.name) != v.dlc:
+		return false
+	}
+	return search == "" || strings.Contains(strings.ToLower(entry.name+" "+strings.Join(entry.variants, " ")), search)
+}
+
+func traitCostText(entry catalogueEntry) string {
+	if entry.kind != "trait" {
+		return ""
+	}
+	return fmt.Sprint(latestCatalogue.trait(entry.name).cost)
+}
+
+// catalogueItemView shows one item of the catalogue, found by its path, and what it can be combined with.
+type catalogueItemView struct {
+	app.Compo
+	entry catalogueEntry
+	found bool
+}
+
+func (v *catalogueItemView) OnPreRender(ctx app.Context) {
+	v.entry, v.found = entryByPath(ctx.Page().URL().Path)
+}
+
+func (v *catalogueItemView) OnNav(ctx app.Context) {
+	v.entry, v.found = entryByPath(ctx.Page().URL().Path)
+}
+
+func (v *catalogueItemView) Render() app.UI {
+	if !v.found {
+		return app.Div().Body(app.A().Href("/catalogue").Text("Catalogue"), app.P().Text("No such item"))
+	}
+	ref := catalogueRef{kind: v.entry.kind, name: v.entry.name}
+	kinds := []string{"authority", "ethic", "civic", "origin"}
+	if ref.kind == "trait" {
+		kinds = []string{"trait"}
+	}
+	return app.Div().Body(
+		app.A().Href("/catalogue").Text("Catalogue"),
+		app.H3().Text(ref.name),
+		app.Label().Text("Kind:"),
+		app.Span().Text(ref.kind),
+		app.Br(),
+		app.If(dlcOf(ref.name) != "", app.Div().Body(
+			app.Label().Text("DLC:"),
+			app.Span().Text(dlcOf(ref.name)),
+		)),
+		app.If(ref.kind == "trait", app.Div().Body(
+			app.Label().Text("Cost:"),
+			app.Span().Text(traitCostText(v.entry)),
+		)),
+		app.Span().Text("Rules:"),
+		app.Range(v.entry.variants).Slice(func(i int) app.UI {
+			return app.Ul().Body(app.Range(strings.Split(v.entry.variants[i], "; ")).Slice(func(j int) app.UI {
+				return app.Li().Text(strings.Split(v.entry.variants[i], "; ")[j])
+			}))
+		}),
+		app.Range(kinds).Slice(func(i int) app.UI {
+			return renderCompatibility(ref, kinds[i])
+		}),
+	)
+}
+
+// renderCompatibility lists the items of a kind that combine with the item, and why the others do not.
+func renderCompatibility(ref catalogueRef, kind string) app.UI {
+	compatible, blocked := []catalogueRef{}, []string{}
+	for _, entry := range pageEntries() {
+		other := catalogueRef{kind: entry.kind, name: entry.name}
+		if entry.kind != kind || other == ref {
+			continue
+		}
+		if reason := incompatibility(ref, other); reason != "" {
+			blocked = append(blocked, entry.name+": "+reason)
+			continue
+		}
+		compatible = append(compatible, other)
+	}
+	return app.Div().Body(
+		app.H4().Text(fmt.Sprintf("Compatible %s (%d)", graphKindTitles[kind], len(compatible))),
+		app.Div().Class("horizontal").Body(app.Range(compatible).Slice(func(i int) app.UI {
+			return app.A().Href(itemPath(compatible[i])).Text(compatible[i].name)
+		})),
+		app.Details().Body(
+			app.Summary().Text(fmt.Sprintf("Incompatible %s (%d)", graphKindTitles[kind], len(blocked))),
+			app.Ul().Body(app.Range(blocked).Slice(func(i int) app.UI {
+				return app.Li().Text(blocked[i])
+			})),
+		),
+	)
+}
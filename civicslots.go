@@ -0,0 +1,79 @@
+package main
+
+import "fmt"
+
+// civicSlotRule changes the civic slots of the empires it applies to. Free slots are filled when the
+// empire is generated, locked slots open later in the game, the first one with the first government reform.
+type civicSlotRule struct {
+	source string
+	when   Predicate
+	free   int
+	locked int
+}
+
+var civicSlotRules = []civicSlotRule{
+	{source: "every empire", when: always, free: 2, locked: 1},
+	{source: "authority Democratic", when: auth("Democratic"), locked: 1},                    // every election brings a reform, so a second slot opens early
+	{source: "origin Imperial Fiefdom", when: includeOrigin("Imperial Fiefdom"), locked: -1}, // the overlord decides on reforms
+	{source: "origin Scion", when: includeOrigin("Scion"), locked: -1},                       // so does the fallen empire
+}
+
+// maxCivicSlots bounds the free slots, the builder has a select for each of them.
+const maxCivicSlots = 4
+
+// civicSlots adds up the rules that apply to the empire. Rules may test the origin, which is drawn
+// after the first civics, so fillEmpire fills the slots an origin opens once it is known.
+func civicSlots(empire Empire) (free int, locked int) {
+	for _, rule := range civicSlotRules {
+		if rule.when.test(empire) {
+			free += rule.free
+			locked += rule.locked
+		}
+	}
+	if free > maxCivicSlots {
+		free = maxCivicSlots
+	}
+	if locked < 0 {
+		locked = 0
+	}
+	return free, locked
+}
+
+// fillCivicSlots draws civics until every free slot is taken.
+func fillCivicSlots(empire Empire) Empire {
+	for free, _ := civicSlots(empire); len(empire.civics) < free; free, _ = civicSlots(empire) {
+		if len(getCivicList(empire, nil)) == 0 {
+			break
+		}
+		empire = chooseCivic(empire)
+	}
+	return empire
+}
+
+// predictCivic draws the civic the empire is likely to take when its first locked slot opens, if asked to.
+// It sees the same rules as the other civics, so it never clashes with them or the origin.
+func predictCivic(empire Empire) Empire {
+	if _, locked := civicSlots(empire); !empire.predictCivic || locked == 0 {
+		return empire
+	}
+	step := empire.trace.begin("predictCivic", "first government reform")
+	civicList := getCivicList(empire, step)
+	if len(civicList) == 0 {
+		return empire
+	}
+	empire.predicted = civicList[r.Intn(len(civicList))]
+	empire.odds.predicted = 1 / float64(len(civicList))
+	if step != nil {
+		for _, civic := range civicList {
+			step.Candidates = append(step.Candidates, civic.name)
+		}
+		step.Drawn = empire.predicted.name
+	}
+	return empire
+}
+
+// civicSlotsText describes the civic slots of the empire, such as "2 free, 1 locked".
+func civicSlotsText(empire Empire) string {
+	free, locked := civicSlots(empire)
+	return fmt.Sprintf("%d free, %d locked", free, locked)
+}
@@ -0,0 +1,24 @@
+package main
+
+import "testing"
+
+func TestCivicSlots(t *testing.T) {
+	tests := []struct {
+		authority, origin string
+		free, locked      int
+	}{
+		{"Oligarchy", "Prosperous Unification", 2, 1},
+		{"Democratic", "Prosperous Unification", 2, 2},
+		{"Oligarchy", "Imperial Fiefdom", 2, 0},
+		{"Dictatorial", "Scion", 2, 0},
+		{"Democratic", "Scion", 2, 1},
+		{"Hive Mind", "Imperial Fiefdom", 2, 0},
+		{"Machine Intelligence", "", 2, 1},
+	}
+	for _, test := range tests {
+		empire := Empire{authority: test.authority, origin: latestCatalogue.origin(test.origin)}
+		if free, locked := civicSlots(empire); free != test.free || locked != test.locked {
+			t.Errorf("%s %s: %d free and %d locked, want %d and %d", test.authority, test.origin, free, locked, test.free, test.locked)
+		}
+	}
+}
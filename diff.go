@@ -0,0 +1,315 @@
+package main
+
+import (
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+)
+
+// catalogueDiff is what changed between two catalogue versions, and which share codes the change breaks.
+type catalogueDiff struct {
+	From    string       `json:"from"`
+	To      string       `json:"to"`
+	Added   []diffItem   `json:"added"`
+	Removed []diffItem   `json:"removed"`
+	Renamed []diffRename `json:"renamed"`
+	Changed []diffChange `json:"changed"`
+	Codes   []codeCheck  `json:"codes,omitempty"`
+}
+
+type diffItem struct {
+	Kind string `json:"kind"`
+	Name string `json:"name"`
+}
+
+type diffRename struct {
+	Kind string `json:"kind"`
+	From string `json:"from"`
+	To   string `json:"to"`
+}
+
+type diffChange struct {
+	Kind   string   `json:"kind"`
+	Name   string   `json:"name"`
+	Before []string `json:"before"`
+	After  []string `json:"after"`
+}
+
+type codeCheck struct {
+	Code    string `json:"code"`
+	Valid   bool   `json:"valid"`
+	Problem string `json:"problem,omitempty"`
+}
+
+// catalogueEntry is one item of a catalogue as the diff sees it. Items that share a name, such as
+// Organic Reprocessing for machines and hives, are one entry with the rules of every variant.
+type catalogueEntry struct {
+	kind     string
+	name     string
+	variants []string // the rules of one variant each, joined with "; "
+}
+
+func catalogueEntries(c *catalogue) []catalogueEntry {
+	res := []catalogueEntry{}
+	add := func(kind string, name string, rules []string) {
+		variant := strings.Join(rules, "; ")
+		if variant == "" {
+			variant = "no rules"
+		}
+		for i, entry := range res {
+			if entry.kind == kind && entry.name == name {
+				if !contains(entry.variants, variant) {
+					res[i].variants = append(res[i].variants, variant)
+				}
+				return
+			}
+		}
+		res = append(res, catalogueEntry{kind: kind, name: name, variants: []string{variant}})
+	}
+	for _, auth := range c.authorities {
+		add("authority", auth.name, auth.isAllowed.rules())
+	}
+	for _, ethic := range allEthics {
+		add("ethic", ethic.name, ethic.isAllowed.rules())
+	}
+	for _, civic := range c.civics {
+		add("civic", civic.name, civic.isAllowed.rules())
+	}
+	for _, origin := range c.origins {
+		add("origin", origin.name, origin.isAllowed.rules())
+	}
+	for _, traits := range [][]Trait{c.traits, c.overtuned} {
+		for _, trait := range traits {
+			add("trait", trait.name, append([]string{fmt.Sprintf("costs %d", trait.cost)}, trait.isAllowed.rules()...))
+		}
+	}
+	for _, popType := range c.popTypes {
+		add("pop type", popType, nil)
+	}
+	return res
+}
+
+// diffCatalogues compares two versions, see compareCatalogues.
+func diffCatalogues(from string, to string) (catalogueDiff, error) {
+	before, err := catalogueFor(from)
+	if err != nil {
+		return catalogueDiff{}, err
+	}
+	after, err := catalogueFor(to)
+	if err != nil {
+		return catalogueDiff{}, err
+	}
+	return compareCatalogues(before, after), nil
+}
+
+// compareCatalogues lists what changed from one catalogue to the other. An item that disappears while an item
+// of the same kind with exactly the same rules appears is reported as renamed, unless other items share those rules.
+func compareCatalogues(before *catalogue, after *catalogue) catalogueDiff {
+	res := catalogueDiff{From: before.version, To: after.version, Added: []diffItem{}, Removed: []diffItem{}, Renamed: []diffRename{}, Changed: []diffChange{}}
+	old, current := catalogueEntries(before), catalogueEntries(after)
+	removed, added := []catalogueEntry{}, []catalogueEntry{}
+	for _, entry := range old {
+		match, ok := findEntry(current, entry.kind, entry.name)
+		switch {
+		case !ok:
+			removed = append(removed, entry)
+		case strings.Join(match.variants, "\n") != strings.Join(entry.variants, "\n"):
+			res.Changed = append(res.Changed, diffChange{Kind: entry.kind, Name: entry.name, Before: entry.variants, After: match.variants})
+		}
+	}
+	for _, entry := range current {
+		if _, ok := findEntry(old, entry.kind, entry.name); !ok {
+			added = append(added, entry)
+		}
+	}
+	renamed := []string{}
+	for _, entry := range removed {
+		candidates := sameRules(added, entry)
+		if len(candidates) == 1 && len(sameRules(removed, candidates[0])) == 1 {
+			res.Renamed = append(res.Renamed, diffRename{Kind: entry.kind, From: entry.name, To: candidates[0].name})
+			renamed = append(renamed, entry.kind+" "+entry.name, candidates[0].kind+" "+candidates[0].name)
+			continue
+		}
+		res.Removed = append(res.Removed, diffItem{Kind: entry.kind, Name: entry.name})
+	}
+	for _, entry := range added {
+		if !contains(renamed, entry.kind+" "+entry.name) {
+			res.Added = append(res.Added, diffItem{Kind: entry.kind, Name: entry.name})
+		}
+	}
+	return res
+}
+
+func findEntry(entries []catalogueEntry, kind string, name string) (catalogueEntry, bool) {
+	for _, entry := range entries {
+		if entry.kind == kind && entry.name == name {
+			return entry, true
+		}
+	}
+	return catalogueEntry{}, false
+}
+
+func sameRules(entries []catalogueEntry, like catalogueEntry) []catalogueEntry {
+	res := []catalogueEntry{}
+	for _, entry := range entries {
+		if entry.kind == like.kind && strings.Join(entry.variants, "\n") == strings.Join(like.variants, "\n") {
+			res = append(res, entry)
+		}
+	}
+	return res
+}
+
+// checkCode tells whether the empire of a share code is still legal under the rules of the given version.
+// The code itself keeps decoding against the version it was made with, this is for people who rebuild
+// the empire in a newer game.
+func checkCode(code string, version string) codeCheck {
+	res := codeCheck{Code: code}
+	empire, err := decodeShareCode(code)
+	if err != nil {
+		res.Problem = err.Error()
+		return res
+	}
+	c, err := catalogueFor(version)
+	if err != nil {
+		res.Problem = err.Error()
+		return res
+	}
+	if empire, res.Problem = relink(c, empire); res.Problem == "" {
+		res.Problem = empireProblem(empire)
+	}
+	res.Valid = res.Problem == ""
+	return res
+}
+
+// relink looks the components of an empire up again in the given catalogue, so the empire follows its
+// rules. It returns the first component the catalogue does not know.
+func relink(c *catalogue, e Empire) (Empire, string) {
+	if !c.has("authority", e.authority) {
+		return e, fmt.Sprintf("unknown authority %q", e.authority)
+	}
+	for _, civic := range append(append([]Civic{}, e.civics...), e.predicted) {
+		if civic.name != "" && !c.has("civic", civic.name) {
+			return e, fmt.Sprintf("unknown civic %q", civic.name)
+		}
+	}
+	if !c.has("origin", e.origin.name) {
+		return e, fmt.Sprintf("unknown origin %q", e.origin.name)
+	}
+	e.version = c.version
+	e.origin = c.origin(e.origin.name)
+	e.civics = append([]Civic{}, e.civics...)
+	for i, civic := range e.civics {
+		others := e
+		others.civics = append(append([]Civic{}, e.civics[:i]...), e.civics[i+1:]...)
+		e.civics[i] = c.civicVariant(others, civic.name)
+	}
+	if e.predicted.name != "" {
+		e.predicted = c.civicVariant(e, e.predicted.name)
+	}
+	for _, species := range []*Species{&e.mainSpecies, &e.subSpecies} {
+		if species.popType == "" {
+			continue
+		}
+		if !c.hasPopType(species.popType) {
+			return e, fmt.Sprintf("unknown pop type %q", species.popType)
+		}
+		species.version = c.version
+		traits := []Trait{}
+		for _, trait := range species.traits {
+			if _, ok := originTraits[trait.name]; !ok {
+				trait = c.trait(trait.name)
+				if trait.isAllowed.kind == "never" {
+					return e, fmt.Sprintf("unknown trait %q", trait.name)
+				}
+			}
+			traits = append(traits, trait)
+		}
+		species.traits = traits
+		for _, trait := range species.traits {
+			if _, ok := originTraits[trait.name]; ok {
+				continue
+			}
+			if reason := trait.isAllowed.reason(*species); reason != "" {
+				return e, trait.name + ": " + reason
+			}
+		}
+	}
+	return e, ""
+}
+
+func writeDiff(w io.Writer, d catalogueDiff) error {
+	lines := []string{"Catalogue " + d.From + " to " + d.To}
+	if len(d.Added)+len(d.Removed)+len(d.Renamed)+len(d.Changed) == 0 {
+		lines = append(lines, "No differences")
+	}
+	for _, item := range d.Added {
+		lines = append(lines, "+ "+item.Kind+" "+item.Name)
+	}
+	for _, item := range d.Removed {
+		lines = append(lines, "- "+item.Kind+" "+item.Name)
+	}
+	for _, item := range d.Renamed {
+		lines = append(lines, "~ "+item.Kind+" "+item.From+" is now "+item.To)
+	}
+	for _, item := range d.Changed {
+		lines = append(lines, "* "+item.Kind+" "+item.Name)
+		for _, rules := range item.Before {
+			lines = append(lines, "    before: "+rules)
+		}
+		for _, rules := range item.After {
+			lines = append(lines, "    after:  "+rules)
+		}
+	}
+	if len(d.Codes) > 0 {
+		lines = append(lines, "", "Share codes under "+d.To)
+	}
+	for _, code := range d.Codes {
+		if code.Valid {
+			lines = append(lines, "  valid    "+code.Code)
+		} else {
+			lines = append(lines, "  INVALID  "+code.Code+": "+code.Problem)
+		}
+	}
+	_, err := fmt.Fprintln(w, strings.Join(lines, "\n"))
+	return err
+}
+
+// runDiff prints what changed between two catalogue versions and checks the given share codes against the second.
+func runDiff(args []string) {
+	flags := flag.NewFlagSet("diff", flag.ExitOnError)
+	format := flags.String("format", "text", "output format: text or json")
+	flags.Usage = func() {
+		fmt.Fprintln(flags.Output(), "usage: diff [-format json] <from version> <to version> [share code...]")
+		flags.PrintDefaults()
+	}
+	flags.Parse(args)
+	if flags.NArg() < 2 {
+		flags.Usage()
+		os.Exit(2)
+	}
+	d, err := diffCatalogues(flags.Arg(0), flags.Arg(1))
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(1)
+	}
+	for _, code := range flags.Args()[2:] {
+		d.Codes = append(d.Codes, checkCode(code, d.To))
+	}
+	switch *format {
+	case "text":
+		err = writeDiff(os.Stdout, d)
+	case "json":
+		enc := json.NewEncoder(os.Stdout)
+		enc.SetIndent("", "  ")
+		err = enc.Encode(d)
+	default:
+		err = fmt.Errorf("unknown format %q", *format)
+	}
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(1)
+	}
+}
@@ -0,0 +1,97 @@
+package main
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestCompareCatalogues(t *testing.T) {
+	democratic := and(auth("Democratic"))
+	imperial := and(auth("Imperial"))
+	before := &catalogue{
+		version: "old",
+		civics: []Civic{
+			{name: "Old Name", isAllowed: democratic},
+			{name: "Gated", isAllowed: democratic},
+			{name: "Dropped", isAllowed: imperial},
+		},
+		origins: []Origin{{name: "Twin A", isAllowed: always}, {name: "Twin B", isAllowed: always}},
+		traits:  []Trait{{name: "Cheap", cost: 1, isAllowed: sAlways}},
+	}
+	after := &catalogue{
+		version: "new",
+		civics: []Civic{
+			{name: "New Name", isAllowed: democratic},
+			{name: "Gated", isAllowed: imperial},
+			{name: "Fresh", isAllowed: and(auth("Corporate"))},
+		},
+		origins: []Origin{{name: "Twin C", isAllowed: always}},
+		traits:  []Trait{{name: "Cheap", cost: 2, isAllowed: sAlways}},
+	}
+	d := compareCatalogues(before, after)
+	if d.From != "old" || d.To != "new" {
+		t.Errorf("diff runs from %q to %q", d.From, d.To)
+	}
+	tests := []struct {
+		name string
+		got  interface{}
+		want interface{}
+	}{
+		{"renamed", d.Renamed, []diffRename{{Kind: "civic", From: "Old Name", To: "New Name"}}},
+		// two origins share the rules of the new one, so neither counts as renamed
+		{"removed", d.Removed, []diffItem{{Kind: "civic", Name: "Dropped"}, {Kind: "origin", Name: "Twin A"}, {Kind: "origin", Name: "Twin B"}}},
+		{"added", d.Added, []diffItem{{Kind: "civic", Name: "Fresh"}, {Kind: "origin", Name: "Twin C"}}},
+		{"changed", d.Changed, []diffChange{
+			{Kind: "civic", Name: "Gated", Before: []string{"requires authority Democratic"}, After: []string{"requires authority Imperial"}},
+			{Kind: "trait", Name: "Cheap", Before: []string{"costs 1"}, After: []string{"costs 2"}},
+		}},
+	}
+	for _, test := range tests {
+		if !reflect.DeepEqual(test.got, test.want) {
+			t.Errorf("%s: got %+v, want %+v", test.name, test.got, test.want)
+		}
+	}
+}
+
+func TestDiffCatalogues(t *testing.T) {
+	tests := []struct {
+		from, to string
+		added    []string // kind and name of the first and last added item, if any
+		removed  []string
+		err      bool
+	}{
+		{from: "3.11", to: latestVersion, added: []string{"civic Rapid Replicator", "civic Rapid Replicator"}},
+		{from: latestVersion, to: "3.11", removed: []string{"civic Rapid Replicator", "civic Rapid Replicator"}},
+		{from: "3.5", to: "3.11", added: []string{"civic Mutagenic Spas", "pop type Toxoid"}},
+		{from: "3.5", to: "3.5"},
+		{from: "", to: latestVersion},
+		{from: "1.0", to: latestVersion, err: true},
+		{from: "3.5", to: "4.0", err: true},
+	}
+	ends := func(items []diffItem) []string {
+		if len(items) == 0 {
+			return nil
+		}
+		first, last := items[0], items[len(items)-1]
+		return []string{first.Kind + " " + first.Name, last.Kind + " " + last.Name}
+	}
+	for _, test := range tests {
+		d, err := diffCatalogues(test.from, test.to)
+		if test.err != (err != nil) {
+			t.Errorf("%s to %s: error %v", test.from, test.to, err)
+			continue
+		}
+		if err != nil {
+			continue
+		}
+		if len(d.Renamed)+len(d.Changed) != 0 {
+			t.Errorf("%s to %s: renamed %+v, changed %+v", test.from, test.to, d.Renamed, d.Changed)
+		}
+		if got := ends(d.Added); !reflect.DeepEqual(got, test.added) {
+			t.Errorf("%s to %s: added %v, want %v", test.from, test.to, got, test.added)
+		}
+		if got := ends(d.Removed); !reflect.DeepEqual(got, test.removed) {
+			t.Errorf("%s to %s: removed %v, want %v", test.from, test.to, got, test.removed)
+		}
+	}
+}
@@ -0,0 +1,68 @@
+package main
+
+// Weights of the components two empires can share. A shared origin or authority
+// changes the playstyle more than a shared ethic or pop type.
+const (
+	sameAuthority     = 3
+	sameAuthorityType = 2
+	sameEthicAxis     = 1
+	sameCivic         = 2
+	sameOrigin        = 3
+	samePopType       = 1
+)
+
+// similarity scores how alike two empires play, 0 meaning they have nothing in common.
+func similarity(a Empire, b Empire) int {
+	res := 0
+	if a.authority == b.authority {
+		res += sameAuthority
+	} else if authorityType(a.authority) == authorityType(b.authority) {
+		res += sameAuthorityType
+	}
+	for _, ethic := range a.ethics {
+		for _, other := range b.ethics {
+			if regularEthic(ethic.name) == regularEthic(other.name) {
+				res += sameEthicAxis
+			}
+		}
+	}
+	for _, civic := range a.civics {
+		for _, other := range b.civics {
+			if civic.name == other.name {
+				res += sameCivic
+			}
+		}
+	}
+	if a.origin.name == b.origin.name {
+		res += sameOrigin
+	}
+	if a.mainSpecies.popType == b.mainSpecies.popType {
+		res += samePopType
+	}
+	return res
+}
+
+// maxSimilarity is the similarity to the most alike of the others.
+func maxSimilarity(empire Empire, others []Empire) int {
+	res := 0
+	for _, other := range others {
+		if score := similarity(empire, other); score > res {
+			res = score
+		}
+	}
+	return res
+}
+
+// authorityType groups the regular authorities, as they play alike compared to corporations and gestalts.
+func authorityType(authority string) string {
+	switch authority {
+	case "Corporate", "Hive Mind", "Machine Intelligence":
+		return authority
+	}
+	return "Regular"
+}
+
+// regularEthic maps a fanatic ethic to its regular form, so both match.
+func regularEthic(name string) string {
+	return ethicByName(name).regularName()
+}
@@ -0,0 +1,119 @@
+package main
+
+import (
+	"fmt"
+	"sort"
+)
+
+// dlcs names the DLC an authority, civic, origin, pop type or trait comes with. Items that are not listed are
+// either in the base game or not recorded yet. Civics of an authority that needs a DLC, such as the
+// corporate civics, are only listed when they need another one.
+var dlcs = map[string]string{
+	"Hive Mind":                 "Utopia",
+	"Machine Intelligence":      "Synthetic Dawn",
+	"Determined Exterminator":   "Synthetic Dawn",
+	"Driven Assimilator":        "Synthetic Dawn",
+	"Rogue Servitor":            "Synthetic Dawn",
+	"Corporate":                 "MegaCorp",
+	"Remnants":                  "Ancient Relics",
+	"Lithoid":                   "Lithoids",
+	"Calamitous Birth":          "Lithoids",
+	"Terravore":                 "Lithoids",
+	"Catalytic Recyclers":       "Lithoids",
+	"Common Ground":             "Federations",
+	"Hegemon":                   "Federations",
+	"Doomsday":                  "Federations",
+	"Lost Colony":               "Federations",
+	"Shattered Ring":            "Federations",
+	"Void Dwellers":             "Federations",
+	"Scion":                     "Federations",
+	"Necroid":                   "Necroids",
+	"Necrophage":                "Necroids",
+	"Death Cult":                "Necroids",
+	"Corporate Death Cult":      "Necroids",
+	"Memorialists":              "Necroids",
+	"Memorialist":               "Necroids",
+	"Reanimators":               "Necroids",
+	"Idyllic Bloom":             "Plantoids",
+	"Aquatic":                   "Aquatics",
+	"Ocean Paradise":            "Aquatics",
+	"Anglers":                   "Aquatics",
+	"Clone Army":                "Humanoids",
+	"Masterful Crafters":        "Humanoids",
+	"Pleasure Seekers":          "Humanoids",
+	"Philosopher King":          "Humanoids",
+	"Imperial Fiefdom":          "Overlord",
+	"Teachers of the Shroud":    "Overlord",
+	"Slingshot to the Stars":    "Overlord",
+	"Subterrenean":              "Overlord",
+	"Toxoid":                    "Toxoids",
+	"Noxious":                   "Toxoids",
+	"Inorganic Breath":          "Toxoids",
+	"Knights of the Toxic God":  "Toxoids",
+	"Overtuned":                 "Toxoids",
+	"Mutagenic Spas":            "Toxoids",
+	"Relentless Industrialists": "Toxoids",
+	"Scavengers":                "Toxoids",
+	"Rapid Replicator":          "The Machine Age",
+}
+
+// dlcOf returns the DLC of the named item, or an empty string when none is recorded.
+// Overtuned traits came with Toxoids, like the origin that unlocks them.
+func dlcOf(name string) string {
+	if dlc, ok := dlcs[name]; ok {
+		return dlc
+	}
+	for _, trait := range latestCatalogue.overtuned {
+		if trait.name == name {
+			return dlcs["Overtuned"]
+		}
+	}
+	return ""
+}
+
+// dlcNames lists every recorded DLC once, sorted by name.
+func dlcNames() []string {
+	res := []string{}
+	for _, dlc := range dlcs {
+		if !contains(res, dlc) {
+			res = append(res, dlc)
+		}
+	}
+	sort.Strings(res)
+	return res
+}
+
+// empireDLCs lists the DLCs the authority, civics, origin and species of the empire come with, each once.
+func empireDLCs(empire Empire) []string {
+	names := []string{empire.authority, empire.origin.name, empire.predicted.name, empire.mainSpecies.popType}
+	for _, civic := range empire.civics {
+		names = append(names, civic.name)
+	}
+	species := []Species{empire.mainSpecies}
+	if len(empire.subSpecies.traits) > 0 {
+		species = append(species, empire.subSpecies)
+		names = append(names, empire.subSpecies.popType)
+	}
+	for _, s := range species {
+		for _, trait := range s.traits {
+			names = append(names, trait.name)
+		}
+	}
+	res := []string{}
+	for _, name := range names {
+		if dlc := dlcOf(name); dlc != "" && !contains(res, dlc) {
+			res = append(res, dlc)
+		}
+	}
+	return res
+}
+
+// checkDLCs returns an error for the first name that is not a recorded DLC.
+func checkDLCs(names []string) error {
+	for _, name := range names {
+		if !contains(dlcNames(), name) {
+			return fmt.Errorf("unknown DLC %q", name)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,46 @@
+package main
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestGenerateBatchWithoutDLCs(t *testing.T) {
+	tests := []struct {
+		name    string
+		missing []string
+		err     bool
+	}{
+		{"no DLC", dlcNames(), false},
+		{"no Toxoids", []string{"Toxoids"}, false},
+		{"unknown DLC", []string{"Nemesis 2"}, true},
+	}
+	for _, test := range tests {
+		empires, err := generateBatch([]string{"", "", "", "", ""}, batchOptions{fanatic: defaultFanaticChance, predict: true, missing: test.missing})
+		if test.err != (err != nil) {
+			t.Errorf("%s: error %v", test.name, err)
+			continue
+		}
+		for _, empire := range empires {
+			for _, dlc := range empireDLCs(empire) {
+				if contains(test.missing, dlc) {
+					t.Errorf("%s: empire %s needs %s", test.name, shareCode(empire), dlc)
+				}
+			}
+		}
+	}
+}
+
+func TestEmpireDLCs(t *testing.T) {
+	empire := Empire{
+		authority:   "Corporate",
+		civics:      []Civic{latestCatalogue.civic("Anglers")},
+		origin:      latestCatalogue.origin("Ocean Paradise"),
+		mainSpecies: Species{popType: "Lithoid", traits: []Trait{latestCatalogue.trait("Aquatic")}},
+		subSpecies:  Species{popType: "Toxoid"}, // no traits, so no sub species
+	}
+	want := []string{"MegaCorp", "Aquatics", "Lithoids"}
+	if got := empireDLCs(empire); !reflect.DeepEqual(got, want) {
+		t.Errorf("got %v, want %v", got, want)
+	}
+}
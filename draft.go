@@ -0,0 +1,466 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/maxence-charriere/go-app/v9/pkg/app"
+)
+
+const (
+	phaseBan  = "ban"
+	phasePick = "pick"
+	phaseDone = "done"
+)
+
+// Card kinds of a draft. Empire cards are whole empires. Origin and civic cards only hold a name,
+// the empire of the player is rolled around the card they pick.
+const (
+	cardEmpire = "empire"
+	cardOrigin = "origin"
+	cardCivic  = "civic"
+)
+
+// draft deals every player a hand of empires, or of origin or civic cards. Players then take turns banning
+// an authority, civic or origin, which rerolls every card in any hand that contains it, and finally take
+// turns picking one card from their own hand. Bans also apply to the empires rolled around picked cards.
+type draft struct {
+	ID            string     `json:"id,omitempty"`
+	Players       []string   `json:"players"`
+	HandSize      int        `json:"handSize"`
+	BansPerPlayer int        `json:"bansPerPlayer"`
+	CardKind      string     `json:"cardKind"`
+	Phase         string     `json:"phase"`
+	Turn          string     `json:"turn,omitempty"`
+	Hands         [][]Empire `json:"hands,omitempty"` // empire cards
+	Cards         [][]string `json:"cards,omitempty"` // origin or civic cards
+	Bans          []ban      `json:"bans"`
+	Picks         []Empire   `json:"picks"`
+	GameVersion   string     `json:"gameVersion"`
+	actions       int        // actions taken in the current phase
+}
+
+type ban struct {
+	Kind   string `json:"kind"` // authority, civic or origin
+	Name   string `json:"name"`
+	Player string `json:"player"`
+}
+
+func newDraft(players []string, handSize int, bansPerPlayer int, version string, cardKind string) (*draft, error) {
+	if len(players) == 0 {
+		return nil, errors.New("a draft needs at least one player")
+	}
+	if handSize <= 0 {
+		return nil, errors.New("hand size must be at least 1")
+	}
+	if cardKind == "" {
+		cardKind = cardEmpire
+	}
+	if cardKind != cardEmpire && cardKind != cardOrigin && cardKind != cardCivic {
+		return nil, fmt.Errorf("unknown card kind %q, use empire, origin or civic", cardKind)
+	}
+	named := []string{}
+	for i, player := range players {
+		if player == "" {
+			player = fmt.Sprintf("Player %d", i+1)
+		}
+		if contains(named, player) {
+			return nil, fmt.Errorf("player %s joined twice", player)
+		}
+		named = append(named, player)
+	}
+	players = named
+	c, err := catalogueFor(version)
+	if err != nil {
+		return nil, err
+	}
+	d := &draft{Players: players, HandSize: handSize, BansPerPlayer: bansPerPlayer, CardKind: cardKind, Phase: phaseBan, Bans: []ban{}, Picks: []Empire{}, GameVersion: c.version}
+	for _, player := range players {
+		if cardKind != cardEmpire {
+			cards := []string{}
+			for i := 0; i < handSize; i++ {
+				card, err := d.dealCard(cards)
+				if err != nil {
+					return nil, err
+				}
+				cards = append(cards, card)
+			}
+			d.Cards = append(d.Cards, cards)
+			continue
+		}
+		hand := []Empire{}
+		for i := 0; i < handSize; i++ {
+			empire, err := rollCandidate(player, d.options(), d.isBanned)
+			if err != nil {
+				return nil, err
+			}
+			hand = append(hand, empire)
+		}
+		d.Hands = append(d.Hands, hand)
+	}
+	if bansPerPlayer <= 0 {
+		d.Phase = phasePick
+	}
+	d.Turn = d.Players[0]
+	return d, nil
+}
+
+// dealCard draws an origin or civic for a hand that is neither banned nor in the hand already.
+func (d *draft) dealCard(hand []string) (string, error) {
+	options := []string{}
+	for _, name := range d.catalogue().names(d.CardKind) {
+		if !contains(hand, name) && !d.bans(d.CardKind, name) {
+			options = append(options, name)
+		}
+	}
+	if len(options) == 0 {
+		return "", fmt.Errorf("no %s left to deal", d.CardKind)
+	}
+	return options[r.Intn(len(options))], nil
+}
+
+// ban removes a component from the draft. Every dealt card that contains it is rerolled. The rerolls go
+// into a copy of the draft, which only replaces the draft once all of them succeeded.
+func (d *draft) ban(player string, kind string, name string) error {
+	if err := d.checkTurn(player, phaseBan); err != nil {
+		return err
+	}
+	if !d.catalogue().has(kind, name) {
+		return fmt.Errorf("unknown %s %q", kind, name)
+	}
+	if d.bans(kind, name) {
+		return fmt.Errorf("%s is already banned", name)
+	}
+	next := d.clone()
+	next.Bans = append(next.Bans, ban{Kind: kind, Name: name, Player: player})
+	for i, hand := range next.Hands {
+		for j, empire := range hand {
+			if !next.isBanned(empire) {
+				continue
+			}
+			replacement, err := rollCandidate(empire.player, next.options(), next.isBanned)
+			if err != nil {
+				return err
+			}
+			next.Hands[i][j] = replacement
+		}
+	}
+	for i, cards := range next.Cards {
+		for j, card := range cards {
+			if !next.bans(next.CardKind, card) {
+				continue
+			}
+			replacement, err := next.dealCard(cards)
+			if err != nil {
+				return err
+			}
+			next.Cards[i][j] = replacement
+		}
+	}
+	next.advance(len(next.Players)*next.BansPerPlayer, phasePick)
+	*d = *next
+	return nil
+}
+
+// clone copies the draft deep enough that changing its hands, bans and picks leaves the original alone.
+func (d *draft) clone() *draft {
+	res := *d
+	res.Hands = [][]Empire{}
+	for _, hand := range d.Hands {
+		res.Hands = append(res.Hands, append([]Empire{}, hand...))
+	}
+	res.Cards = [][]string{}
+	for _, cards := range d.Cards {
+		res.Cards = append(res.Cards, append([]string{}, cards...))
+	}
+	res.Bans = append([]ban{}, d.Bans...)
+	res.Picks = append([]Empire{}, d.Picks...)
+	return &res
+}
+
+// catalogue is the catalogue of the version the draft follows.
+func (d *draft) catalogue() *catalogue {
+	return catalogueOf(d.GameVersion)
+}
+
+// options are the batch options every card of the draft is rolled with.
+func (d *draft) options() batchOptions {
+	return batchOptions{fanatic: defaultFanaticChance, version: d.GameVersion}
+}
+
+// pick locks the chosen card of the player's hand as their empire. An origin or civic card first
+// rolls the empire around it.
+func (d *draft) pick(player string, card int) error {
+	if err := d.checkTurn(player, phasePick); err != nil {
+		return err
+	}
+	index := d.playerIndex(player)
+	if d.CardKind != cardEmpire {
+		cards := d.Cards[index]
+		if card < 0 || card >= len(cards) {
+			return fmt.Errorf("card %d is not in the hand of %s", card, player)
+		}
+		empire, err := d.rollAround(player, cards[card])
+		if err != nil {
+			return err
+		}
+		d.Picks = append(d.Picks, empire)
+		d.Cards[index] = []string{}
+		d.advance(len(d.Players), phaseDone)
+		return nil
+	}
+	hand := d.Hands[index]
+	if card < 0 || card >= len(hand) {
+		return fmt.Errorf("card %d is not in the hand of %s", card, player)
+	}
+	d.Picks = append(d.Picks, hand[card])
+	d.Hands[index] = []Empire{}
+	d.advance(len(d.Players), phaseDone)
+	return nil
+}
+
+// rollAround rolls an empire for the player that starts from the origin or civic of a card. Civics that
+// come in variants try each of them, as only some suit the rest of the empire.
+func (d *draft) rollAround(player string, card string) (Empire, error) {
+	c := d.catalogue()
+	opts := d.options()
+	if d.CardKind == cardOrigin {
+		opts.preset = Empire{origin: c.origin(card)}
+		return rollCandidate(player, opts, d.isBanned)
+	}
+	err := fmt.Errorf("unknown civic %q", card)
+	for _, i := range r.Perm(len(c.civics)) {
+		if c.civics[i].name != card {
+			continue
+		}
+		opts.preset = Empire{civics: []Civic{c.civics[i]}}
+		var empire Empire
+		if empire, err = rollCandidate(player, opts, d.isBanned); err == nil {
+			return empire, nil
+		}
+	}
+	return Empire{}, err
+}
+
+func (d *draft) checkTurn(player string, phase string) error {
+	if d.Phase != phase {
+		return fmt.Errorf("the draft is in the %s phase", d.Phase)
+	}
+	if d.Turn != player {
+		return fmt.Errorf("it is the turn of %s", d.Turn)
+	}
+	return nil
+}
+
+// advance hands the turn to the next player, moving on to the next phase once every action of this one is taken.
+func (d *draft) advance(phaseActions int, next string) {
+	d.actions++
+	if d.actions >= phaseActions {
+		d.actions = 0
+		d.Phase = next
+	}
+	d.Turn = ""
+	if d.Phase != phaseDone {
+		d.Turn = d.Players[d.actions%len(d.Players)]
+	}
+}
+
+func (d *draft) playerIndex(player string) int {
+	for i, p := range d.Players {
+		if p == player {
+			return i
+		}
+	}
+	return -1
+}
+
+func (d *draft) isBanned(empire Empire) bool {
+	if d.bans("authority", empire.authority) || d.bans("origin", empire.origin.name) {
+		return true
+	}
+	for _, civic := range empire.civics {
+		if d.bans("civic", civic.name) {
+			return true
+		}
+	}
+	return false
+}
+
+// bans tells whether the component is banned.
+func (d *draft) bans(kind string, name string) bool {
+	for _, b := range d.Bans {
+		if b.Kind == kind && b.Name == name {
+			return true
+		}
+	}
+	return false
+}
+
+// has tells whether the catalogue has an authority, civic or origin of that name.
+func (c *catalogue) has(kind string, name string) bool {
+	return contains(c.names(kind), name)
+}
+
+// names lists the authorities, civics or origins of the catalogue, each name once. These are also what a draft can ban.
+func (c *catalogue) names(kind string) []string {
+	res := []string{}
+	switch kind {
+	case "authority":
+		for _, auth := range c.authorities {
+			res = append(res, auth.name)
+		}
+	case "civic":
+		for _, civic := range c.civics {
+			if !contains(res, civic.name) {
+				res = append(res, civic.name)
+			}
+		}
+	case "origin":
+		for _, origin := range c.origins {
+			res = append(res, origin.name)
+		}
+	}
+	return res
+}
+
+// draftView runs a draft in the browser, with all players sharing one screen.
+type draftView struct {
+	app.Compo
+	players  string
+	handSize int
+	bans     int
+	banKind  string
+	banName  string
+	version  string
+	cardKind string
+	draft    *draft
+	message  string
+}
+
+func (v *draftView) OnInit() {
+	v.handSize = 3
+	v.bans = 1
+	v.banKind = "origin"
+	v.cardKind = cardEmpire
+}
+
+func (v *draftView) Render() app.UI {
+	return app.Div().Body(
+		app.A().Href("/").Text("Random empires"),
+		app.Br(),
+		app.Label().Text("Players:").For("players"),
+		app.Input().ID("players").Placeholder("comma separated names").Value(v.players).OnChange(v.ValueTo(&v.players)),
+		app.Label().Text("Hand size:").For("handSize"),
+		app.Input().ID("handSize").Type("number").Min(1).Value(v.handSize).OnChange(v.ValueTo(&v.handSize)),
+		app.Label().Text("Bans per player:").For("bans"),
+		app.Input().ID("bans").Type("number").Min(0).Value(v.bans).OnChange(v.ValueTo(&v.bans)),
+		app.Label().Text("Game version:").For("version"),
+		versionSelect("version", &v.version),
+		app.Label().Text("Cards:").For("cardKind"),
+		app.Select().ID("cardKind").OnChange(v.ValueTo(&v.cardKind)).Body(
+			app.Range([]string{cardEmpire, cardOrigin, cardCivic}).Slice(func(i int) app.UI {
+				kind := []string{cardEmpire, cardOrigin, cardCivic}[i]
+				return app.Option().Value(kind).Text(kind + "s").Selected(kind == v.cardKind)
+			}),
+		),
+		app.Button().Text("Deal").OnClick(v.deal),
+		app.If(v.message != "", app.Span().Text(v.message)),
+		app.If(v.draft != nil, v.renderDraft()),
+	)
+}
+
+func (v *draftView) renderDraft() app.UI {
+	d := v.draft
+	if d == nil {
+		return app.Text("")
+	}
+	return app.Div().Body(
+		app.H3().Text(draftStatus(d)),
+		app.If(d.Phase == phaseBan, app.Div().Body(
+			app.Select().OnChange(v.ValueTo(&v.banKind)).Body(
+				app.Range([]string{"origin", "civic", "authority"}).Slice(func(i int) app.UI {
+					kind := []string{"origin", "civic", "authority"}[i]
+					return app.Option().Value(kind).Text(kind).Selected(kind == v.banKind)
+				}),
+			),
+			app.Select().OnChange(v.ValueTo(&v.banName)).Body(
+				app.Option().Value("").Text("-"),
+				app.Range(d.catalogue().names(v.banKind)).Slice(func(i int) app.UI {
+					name := d.catalogue().names(v.banKind)[i]
+					return app.Option().Value(name).Text(name).Selected(name == v.banName)
+				}),
+			),
+			app.Button().Text("Ban").OnClick(v.ban),
+		)),
+		app.If(len(d.Bans) > 0, app.Ul().Body(app.Range(d.Bans).Slice(func(i int) app.UI {
+			return app.Li().Text(fmt.Sprintf("%s banned %s %s", d.Bans[i].Player, d.Bans[i].Kind, d.Bans[i].Name))
+		}))),
+		app.Range(d.Hands).Slice(func(i int) app.UI {
+			return app.Div().Body(
+				app.If(len(d.Hands[i]) > 0, app.H4().Text("Hand of "+d.Players[i])),
+				app.Div().Class("horizontal").Body(app.Range(d.Hands[i]).Slice(func(j int) app.UI {
+					return app.Div().Body(
+						renderEmpire(d.Hands[i][j]),
+						app.If(d.Phase == phasePick && d.Turn == d.Players[i], app.Button().Text("Pick").OnClick(v.pick(j))),
+					)
+				})),
+			)
+		}),
+		app.Range(d.Cards).Slice(func(i int) app.UI {
+			return app.Div().Body(
+				app.If(len(d.Cards[i]) > 0, app.H4().Text("Hand of "+d.Players[i])),
+				app.Div().Class("horizontal").Body(app.Range(d.Cards[i]).Slice(func(j int) app.UI {
+					card := catalogueRef{kind: d.CardKind, name: d.Cards[i][j]}
+					return app.Div().Class("card").Body(
+						app.Span().Text(card.kind+": "),
+						app.A().Href(itemPath(card)).Text(card.name),
+						app.If(d.Phase == phasePick && d.Turn == d.Players[i], app.Button().Text("Pick").OnClick(v.pick(j))),
+					)
+				})),
+			)
+		}),
+		app.If(len(d.Picks) > 0, app.Div().Body(
+			app.H4().Text("Picks"),
+			app.Div().Class("horizontal").Body(app.Range(d.Picks).Slice(func(i int) app.UI {
+				return renderEmpire(d.Picks[i])
+			})),
+		)),
+	)
+}
+
+func draftStatus(d *draft) string {
+	switch d.Phase {
+	case phaseBan:
+		return d.Turn + " bans"
+	case phasePick:
+		return d.Turn + " picks"
+	}
+	return "The draft is complete"
+}
+
+func (v *draftView) deal(ctx app.Context, e app.Event) {
+	d, err := newDraft(playerList(v.players, 0), v.handSize, v.bans, v.version, v.cardKind)
+	v.message = ""
+	if err != nil {
+		v.message = err.Error()
+		return
+	}
+	v.draft = d
+}
+
+func (v *draftView) ban(ctx app.Context, e app.Event) {
+	v.message = ""
+	if err := v.draft.ban(v.draft.Turn, v.banKind, v.banName); err != nil {
+		v.message = err.Error()
+	}
+	v.banName = ""
+}
+
+func (v *draftView) pick(card int) app.EventHandler {
+	return func(ctx app.Context, e app.Event) {
+		v.message = ""
+		if err := v.draft.pick(v.draft.Turn, card); err != nil {
+			v.message = err.Error()
+		}
+	}
+}
@@ -3,6 +3,8 @@ module stellaris.helderman.xyz/stellaris
 go 1.19
 
 require (
-	github.com/google/uuid v1.3.0 // indirect
-	github.com/maxence-charriere/go-app/v9 v9.5.1 // indirect
+	github.com/gorilla/websocket v1.5.0
+	github.com/maxence-charriere/go-app/v9 v9.5.1
 )
+
+require github.com/google/uuid v1.3.0 // indirect
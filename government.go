@@ -0,0 +1,66 @@
+package main
+
+// Government is a named form of government. Like in the game files, the first entry
+// of allGovernments that allows the empire names it, so specific forms come before generic ones.
+type Government struct {
+	name      string
+	isAllowed Predicate
+}
+
+var allGovernments = []Government{
+	// gestalt variants
+	{name: "Devouring Swarm", isAllowed: and(auth("Hive Mind"), includeCivic("Devouring Swarm"))},
+	{name: "Terravore Hive", isAllowed: and(auth("Hive Mind"), includeCivic("Terravore"))},
+	{name: "Hive Mind", isAllowed: auth("Hive Mind")},
+	{name: "Determined Exterminator", isAllowed: and(auth("Machine Intelligence"), includeCivic("Determined Exterminator"))},
+	{name: "Driven Assimilator", isAllowed: and(auth("Machine Intelligence"), includeCivic("Driven Assimilator"))},
+	{name: "Rogue Servitor", isAllowed: and(auth("Machine Intelligence"), includeCivic("Rogue Servitor"))},
+	{name: "Machine Intelligence", isAllowed: auth("Machine Intelligence")},
+	// megacorporations
+	{name: "Criminal Syndicate", isAllowed: and(auth("Corporate"), includeCivic("Criminal Heritage"))},
+	{name: "Megachurch", isAllowed: and(auth("Corporate"), includeCivic("Gospel of the Masses"))},
+	{name: "Megacorporation", isAllowed: auth("Corporate")},
+	// civics take precedence over ethics
+	{name: "Theocratic Oligarchy", isAllowed: and(auth("Oligarchy"), includeCivic("Exalted Priesthood"))},
+	{name: "Holy Tribunal", isAllowed: and(auth("Dictatorial"), includeCivic("Exalted Priesthood"))},
+	{name: "Divine Empire", isAllowed: and(auth("Imperial"), includeCivic("Imperial Cult"))},
+	{name: "Feudal Realm", isAllowed: and(auth("Imperial"), includeCivic("Feudal Society"))},
+	{name: "Science Directorate", isAllowed: and(auth("Democratic", "Oligarchy"), includeCivic("Technocracy"))},
+	{name: "Illuminated Autocracy", isAllowed: and(auth("Dictatorial", "Imperial"), includeCivic("Technocracy"))},
+	{name: "Plutocratic Oligarchy", isAllowed: and(auth("Oligarchy"), includeCivic("Merchant Guilds"))},
+	{name: "Aristocratic Oligarchy", isAllowed: and(auth("Oligarchy"), includeCivic("Aristocratic Elite"))},
+	{name: "Citizen Republic", isAllowed: and(auth("Democratic"), includeCivic("Citizen Service"))},
+	{name: "Enlightened Monarchy", isAllowed: and(auth("Dictatorial", "Imperial"), includeCivic("Philosopher King"))},
+	// fanatic ethics
+	{name: "Military Junta", isAllowed: and(auth("Oligarchy", "Dictatorial"), includeEthic("Fanatic Militarist"))},
+	{name: "Military Empire", isAllowed: and(auth("Imperial"), includeEthic("Fanatic Militarist"))},
+	{name: "Moral Democracy", isAllowed: and(auth("Democratic"), includeEthic("Fanatic Spiritualist"))},
+	{name: "Theocratic Dictatorship", isAllowed: and(auth("Dictatorial"), includeEthic("Fanatic Spiritualist"))},
+	{name: "Holy Empire", isAllowed: and(auth("Imperial"), includeEthic("Fanatic Spiritualist"))},
+	{name: "Direct Democracy", isAllowed: and(auth("Democratic"), includeEthic("Fanatic Egalitarian"))},
+	{name: "Despotic Hegemony", isAllowed: and(auth("Dictatorial"), includeEthic("Fanatic Authoritarian"))},
+	{name: "Despotic Empire", isAllowed: and(auth("Imperial"), includeEthic("Fanatic Authoritarian"))},
+	{name: "Irenic Republic", isAllowed: and(auth("Democratic", "Oligarchy"), includeEthic("Fanatic Pacifist"))},
+	{name: "Irenic Monarchy", isAllowed: and(auth("Dictatorial", "Imperial"), includeEthic("Fanatic Pacifist"))},
+	{name: "Technocratic Republic", isAllowed: and(auth("Democratic", "Oligarchy"), includeEthic("Fanatic Materialist"))},
+	{name: "Xenophobic Autocracy", isAllowed: and(auth("Dictatorial", "Imperial"), includeEthic("Fanatic Xenophobe"))},
+	{name: "Cosmopolitan Republic", isAllowed: and(auth("Democratic", "Oligarchy"), includeEthic("Fanatic Xenophile"))},
+	// regular ethics
+	{name: "Theocratic Republic", isAllowed: and(auth("Democratic", "Oligarchy"), includeEthic("Spiritualist"))},
+	{name: "Military Dictatorship", isAllowed: and(auth("Dictatorial"), includeEthic("Militarist"))},
+	// defaults per authority
+	{name: "Democratic Republic", isAllowed: auth("Democratic")},
+	{name: "Oligarchic Republic", isAllowed: auth("Oligarchy")},
+	{name: "Dictatorship", isAllowed: auth("Dictatorial")},
+	{name: "Star Empire", isAllowed: auth("Imperial")},
+}
+
+// governmentName resolves the form of government of the empire, or returns its authority if none matches.
+func governmentName(empire Empire) string {
+	for _, government := range allGovernments {
+		if government.isAllowed.test(empire) {
+			return government.name
+		}
+	}
+	return empire.authority
+}
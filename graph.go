@@ -0,0 +1,281 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+)
+
+// graphKinds are the subsystems of the compatibility graph, in the order they are drawn.
+var graphKinds = []string{"authority", "ethic", "civic", "origin", "trait"}
+
+// graphKindTitles label the clusters of the exported graph.
+var graphKindTitles = map[string]string{"authority": "Authorities", "ethic": "Ethics", "civic": "Civics", "origin": "Origins", "trait": "Traits"}
+
+// graphKindPlurals let -only name a subsystem in the plural too.
+var graphKindPlurals = map[string]string{"authorities": "authority", "ethics": "ethic", "civics": "civic", "origins": "origin", "traits": "trait"}
+
+// Edge relations. A rule naming several options becomes one "requires one of" edge to each of them.
+const (
+	relRequires    = "requires"
+	relRequiresAny = "requires one of"
+	relExcludes    = "excludes"
+	relGrants      = "grants"
+)
+
+type graphNode struct {
+	kind string
+	name string
+}
+
+type graphEdge struct {
+	from     graphNode
+	to       graphNode
+	relation string
+}
+
+// compatibilityGraph holds the rules of the catalogue as nodes and edges. Exclusions are usually listed on
+// both sides, they become a single undirected edge.
+type compatibilityGraph struct {
+	nodes []graphNode
+	edges []graphEdge
+}
+
+// buildGraph turns the rules of the catalogue into a graph, keeping the nodes of the given kinds
+// and the edges between them.
+func buildGraph(c *catalogue, kinds []string) compatibilityGraph {
+	g := compatibilityGraph{}
+	addNode := func(kind string, name string) {
+		node := graphNode{kind: kind, name: name}
+		if contains(kinds, kind) && !g.hasNode(node) {
+			g.nodes = append(g.nodes, node)
+		}
+	}
+	for _, auth := range c.authorities {
+		addNode("authority", auth.name)
+	}
+	for _, ethic := range allEthics {
+		addNode("ethic", ethic.name)
+	}
+	for _, civic := range c.civics {
+		addNode("civic", civic.name)
+	}
+	for _, origin := range c.origins {
+		addNode("origin", origin.name)
+	}
+	for _, traits := range [][]Trait{c.traits, c.overtuned} {
+		for _, trait := range traits {
+			addNode("trait", trait.name)
+		}
+	}
+	for _, auth := range c.authorities {
+		g.addRules(graphNode{kind: "authority", name: auth.name}, auth.isAllowed)
+		g.addGrants(graphNode{kind: "authority", name: auth.name}, auth.species)
+	}
+	for _, ethic := range allEthics {
+		g.addRules(graphNode{kind: "ethic", name: ethic.name}, ethic.isAllowed)
+		for _, other := range allEthics { // see ethicAxisProblem
+			if other.axis == ethic.axis {
+				g.addEdge(graphEdge{from: graphNode{kind: "ethic", name: ethic.name}, to: graphNode{kind: "ethic", name: other.name}, relation: relExcludes})
+			}
+		}
+	}
+	for _, civic := range c.civics {
+		g.addRules(graphNode{kind: "civic", name: civic.name}, civic.isAllowed)
+		g.addGrants(graphNode{kind: "civic", name: civic.name}, civic.species)
+	}
+	for _, origin := range c.origins {
+		g.addRules(graphNode{kind: "origin", name: origin.name}, origin.isAllowed)
+		g.addGrants(graphNode{kind: "origin", name: origin.name}, origin.species)
+	}
+	for _, traits := range [][]Trait{c.traits, c.overtuned} {
+		for _, trait := range traits {
+			g.addTraitRules(graphNode{kind: "trait", name: trait.name}, trait.isAllowed)
+		}
+	}
+	return g
+}
+
+func (g *compatibilityGraph) hasNode(node graphNode) bool {
+	for _, existing := range g.nodes {
+		if existing == node {
+			return true
+		}
+	}
+	return false
+}
+
+// addEdge adds the edge if both ends are in the graph and it is not there yet, in either direction for exclusions.
+func (g *compatibilityGraph) addEdge(edge graphEdge) {
+	if !g.hasNode(edge.from) || !g.hasNode(edge.to) || edge.from == edge.to {
+		return
+	}
+	for _, existing := range g.edges {
+		if existing == edge || edge.relation == relExcludes && existing == (graphEdge{from: edge.to, to: edge.from, relation: relExcludes}) {
+			return
+		}
+	}
+	g.edges = append(g.edges, edge)
+}
+
+func (g *compatibilityGraph) addRules(from graphNode, p Predicate) {
+	kinds := map[string]string{"auth": "authority", "notAuth": "authority", "includeCivic": "civic", "excludeCivic": "civic", "includeEthic": "ethic", "excludeEthic": "ethic", "includeOrigin": "origin"}
+	switch p.kind {
+	case "and":
+		for _, part := range p.parts {
+			g.addRules(from, part)
+		}
+		return
+	case "notAuth", "excludeCivic", "excludeEthic":
+		for _, name := range p.names {
+			g.addEdge(graphEdge{from: from, to: graphNode{kind: kinds[p.kind], name: name}, relation: relExcludes})
+		}
+	case "auth", "includeCivic", "includeEthic", "includeOrigin":
+		relation := relRequires
+		if len(p.names) > 1 {
+			relation = relRequiresAny
+		}
+		for _, name := range p.names {
+			g.addEdge(graphEdge{from: from, to: graphNode{kind: kinds[p.kind], name: name}, relation: relation})
+		}
+	}
+}
+
+func (g *compatibilityGraph) addTraitRules(from graphNode, p speciesPredicate) {
+	switch p.kind {
+	case "and":
+		for _, part := range p.parts {
+			g.addTraitRules(from, part)
+		}
+	case "excludeTrait":
+		for _, name := range p.names {
+			g.addEdge(graphEdge{from: from, to: graphNode{kind: "trait", name: name}, relation: relExcludes})
+		}
+	}
+}
+
+// addGrants links the traits a component forces on the species. Most of them are in originTraits and
+// only show up in the graph when a regular trait has the same name, such as Aquatic.
+func (g *compatibilityGraph) addGrants(from graphNode, rules speciesRules) {
+	for _, name := range append(append([]string{}, rules.traits...), rules.subTraits...) {
+		g.addEdge(graphEdge{from: from, to: graphNode{kind: "trait", name: name}, relation: relGrants})
+	}
+}
+
+// writeDOT writes the graph for Graphviz, one cluster per subsystem. Requirements are solid arrows,
+// exclusions dashed red lines and granted traits dotted arrows.
+func writeDOT(w io.Writer, g compatibilityGraph) error {
+	lines := []string{"digraph catalogue {", "  rankdir=LR;", "  node [shape=box];"}
+	for _, kind := range graphKinds {
+		cluster := []string{}
+		for _, node := range g.nodes {
+			if node.kind == kind {
+				cluster = append(cluster, fmt.Sprintf("    %q [label=%q];", node.kind+":"+node.name, node.name))
+			}
+		}
+		if len(cluster) == 0 {
+			continue
+		}
+		lines = append(lines, fmt.Sprintf("  subgraph cluster_%s {", kind), fmt.Sprintf("    label=%q;", graphKindTitles[kind]))
+		lines = append(lines, cluster...)
+		lines = append(lines, "  }")
+	}
+	styles := map[string]string{
+		relRequires:    `label="requires"`,
+		relRequiresAny: `label="requires one of", style=bold`,
+		relExcludes:    `label="excludes", style=dashed, color=red, dir=none`,
+		relGrants:      `label="grants", style=dotted`,
+	}
+	for _, edge := range g.edges {
+		lines = append(lines, fmt.Sprintf("  %q -> %q [%s];", edge.from.kind+":"+edge.from.name, edge.to.kind+":"+edge.to.name, styles[edge.relation]))
+	}
+	lines = append(lines, "}")
+	_, err := fmt.Fprintln(w, strings.Join(lines, "\n"))
+	return err
+}
+
+// writeMermaid writes the graph as a Mermaid flowchart. Mermaid ids may not contain spaces,
+// so nodes are numbered and carry their name as label.
+func writeMermaid(w io.Writer, g compatibilityGraph) error {
+	ids := map[graphNode]string{}
+	lines := []string{"flowchart LR"}
+	for _, kind := range graphKinds {
+		cluster := []string{}
+		for _, node := range g.nodes {
+			if node.kind == kind {
+				ids[node] = fmt.Sprintf("%s%d", kind, len(ids))
+				cluster = append(cluster, fmt.Sprintf("    %s[\"%s\"]", ids[node], strings.ReplaceAll(node.name, `"`, "#quot;")))
+			}
+		}
+		if len(cluster) == 0 {
+			continue
+		}
+		lines = append(lines, fmt.Sprintf("  subgraph cluster_%s [%s]", kind, graphKindTitles[kind]))
+		lines = append(lines, cluster...)
+		lines = append(lines, "  end")
+	}
+	arrows := map[string]string{
+		relRequires:    "-->|requires|",
+		relRequiresAny: "-->|requires one of|",
+		relExcludes:    "-.-|excludes|",
+		relGrants:      "-.->|grants|",
+	}
+	for _, edge := range g.edges {
+		lines = append(lines, fmt.Sprintf("  %s %s %s", ids[edge.from], arrows[edge.relation], ids[edge.to]))
+	}
+	_, err := fmt.Fprintln(w, strings.Join(lines, "\n"))
+	return err
+}
+
+// parseKinds reads a comma separated list of graph subsystems, all of them when it is empty.
+func parseKinds(s string) ([]string, error) {
+	if strings.TrimSpace(s) == "" {
+		return graphKinds, nil
+	}
+	res := []string{}
+	for _, part := range strings.Split(s, ",") {
+		kind := strings.TrimSpace(part)
+		if singular, ok := graphKindPlurals[kind]; ok {
+			kind = singular
+		}
+		if !contains(graphKinds, kind) {
+			return nil, fmt.Errorf("unknown subsystem %q, use %s", part, strings.Join(graphKinds, ", "))
+		}
+		res = append(res, kind)
+	}
+	return res, nil
+}
+
+// runGraph prints the compatibility graph of the catalogue.
+func runGraph(args []string) {
+	flags := flag.NewFlagSet("graph", flag.ExitOnError)
+	format := flags.String("format", "dot", "output format: dot or mermaid")
+	only := flags.String("only", "", "comma separated subsystems to include, out of "+strings.Join(graphKinds, ", "))
+	version := flags.String("game-version", latestVersion, "catalogue version to draw, see generate")
+	flags.Parse(args)
+	kinds, err := parseKinds(*only)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(1)
+	}
+	c, err := catalogueFor(*version)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(1)
+	}
+	g := buildGraph(c, kinds)
+	switch *format {
+	case "dot":
+		err = writeDOT(os.Stdout, g)
+	case "mermaid":
+		err = writeMermaid(os.Stdout, g)
+	default:
+		err = fmt.Errorf("unknown format %q", *format)
+	}
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(1)
+	}
+}
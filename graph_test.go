@@ -0,0 +1,40 @@
+package main
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestParseKinds(t *testing.T) {
+	tests := []struct {
+		in   string
+		want []string
+		err  bool
+	}{
+		{in: "", want: graphKinds},
+		{in: "authority, civics", want: []string{"authority", "civic"}},
+		{in: "authorities,ethics,origins,traits", want: []string{"authority", "ethic", "origin", "trait"}},
+		{in: "authoritys", err: true},
+		{in: "authoritie", err: true},
+		{in: "civic,species", err: true},
+	}
+	for _, test := range tests {
+		got, err := parseKinds(test.in)
+		if test.err != (err != nil) || !test.err && !reflect.DeepEqual(got, test.want) {
+			t.Errorf("%q: got %v, %v, want %v", test.in, got, err, test.want)
+		}
+	}
+}
+
+func TestWriteMermaidSubgraphs(t *testing.T) {
+	out := &strings.Builder{}
+	if err := writeMermaid(out, buildGraph(latestCatalogue, graphKinds)); err != nil {
+		t.Fatal(err)
+	}
+	for kind, title := range graphKindTitles {
+		if line := "  subgraph cluster_" + kind + " [" + title + "]\n"; !strings.Contains(out.String(), line) {
+			t.Errorf("no line %q", line)
+		}
+	}
+}
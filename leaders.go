@@ -0,0 +1,194 @@
+package main
+
+import (
+	"fmt"
+	"math/rand"
+	"strings"
+)
+
+// leaderClasses are the starting leaders of every empire, in the order they are shown.
+var leaderClasses = []string{"ruler", "scientist", "admiral", "general"}
+
+// leaderTraitPoints is what the starting traits of a leader may cost in total, per class.
+var leaderTraitPoints = map[string]int{"ruler": 2, "scientist": 1, "admiral": 1, "general": 1}
+
+// maxLeaderTraits bounds the traits of one leader, negative traits included.
+const maxLeaderTraits = 3
+
+// maxLeaderTries works like the retries of fillSpecies: a leader whose points do not add up is rolled again.
+const maxLeaderTries = 100
+
+// LeaderTrait is a trait a starting leader may have. Negative traits cost negative points and pay for
+// another positive trait. isAllowed tests the empire, species the main species the leaders belong to.
+type LeaderTrait struct {
+	name      string
+	cost      int
+	classes   []string
+	isAllowed Predicate
+	species   speciesPredicate
+	excludes  []string
+}
+
+// Leader is one of the starting leaders of an empire.
+type Leader struct {
+	class  string
+	name   string
+	traits []LeaderTrait
+}
+
+var biological = inArchetype("Biological", "Lithoid")
+
+var allLeaderTraits = []LeaderTrait{
+	// ruler
+	{name: "Architectural Sense", cost: 1, classes: []string{"ruler"}, isAllowed: always, species: sAlways},
+	{name: "Industrialist", cost: 1, classes: []string{"ruler"}, isAllowed: always, species: sAlways},
+	{name: "Expansionist", cost: 1, classes: []string{"ruler"}, isAllowed: always, species: sAlways},
+	{name: "Warlike", cost: 1, classes: []string{"ruler"}, isAllowed: excludeEthic("Pacifist", "Fanatic Pacifist"), species: sAlways},
+	{name: "Charismatic", cost: 1, classes: []string{"ruler"}, isAllowed: normalAuth(), species: sAlways},
+	{name: "Champion of the People", cost: 2, classes: []string{"ruler"}, isAllowed: auth("Democratic"), species: sAlways},
+	{name: "Reformer", cost: 1, classes: []string{"ruler"}, isAllowed: auth("Democratic", "Oligarchy"), species: sAlways},
+	{name: "Iron Fist", cost: 1, classes: []string{"ruler"}, isAllowed: and(normalAuth(), includeEthic("Authoritarian", "Fanatic Authoritarian")), species: sAlways},
+	{name: "Fertility Preacher", cost: 1, classes: []string{"ruler"}, isAllowed: notAuth("Machine Intelligence"), species: andS(biological, excludeTrait("Lithoid"))},
+	{name: "Corporate Dynast", cost: 1, classes: []string{"ruler"}, isAllowed: auth("Corporate"), species: sAlways},
+	{name: "Synaptic Node", cost: 2, classes: []string{"ruler"}, isAllowed: auth("Hive Mind"), species: sAlways},
+	{name: "Nexus Processor", cost: 2, classes: []string{"ruler"}, isAllowed: auth("Machine Intelligence"), species: sAlways},
+	// scientist
+	{name: "Meticulous", cost: 1, classes: []string{"scientist"}, isAllowed: always, species: sAlways, excludes: []string{"Sloppy"}},
+	{name: "Spark of Genius", cost: 1, classes: []string{"scientist"}, isAllowed: always, species: sAlways},
+	{name: "Archaeologist", cost: 1, classes: []string{"scientist"}, isAllowed: always, species: sAlways},
+	{name: "Roamer", cost: 1, classes: []string{"scientist"}, isAllowed: always, species: sAlways},
+	{name: "Maniacal", cost: 1, classes: []string{"scientist"}, isAllowed: always, species: sAlways},
+	{name: "Expertise: Biology", cost: 1, classes: []string{"scientist"}, isAllowed: always, species: sAlways},
+	{name: "Expertise: Computing", cost: 1, classes: []string{"scientist"}, isAllowed: always, species: sAlways},
+	{name: "Expertise: Psionics", cost: 1, classes: []string{"scientist"}, isAllowed: and(normalAuth(), includeEthic("Spiritualist", "Fanatic Spiritualist")), species: biological},
+	{name: "Sloppy", cost: -1, classes: []string{"scientist"}, isAllowed: always, species: sAlways, excludes: []string{"Meticulous"}},
+	// admiral
+	{name: "Aggressive", cost: 1, classes: []string{"admiral"}, isAllowed: always, species: sAlways, excludes: []string{"Cautious"}},
+	{name: "Cautious", cost: 1, classes: []string{"admiral"}, isAllowed: always, species: sAlways, excludes: []string{"Aggressive"}},
+	{name: "Scout", cost: 1, classes: []string{"admiral"}, isAllowed: always, species: sAlways},
+	{name: "Engineer", cost: 1, classes: []string{"admiral"}, isAllowed: always, species: sAlways},
+	{name: "Gale-Speed Navigator", cost: 2, classes: []string{"admiral"}, isAllowed: always, species: sAlways},
+	{name: "Trickster", cost: 1, classes: []string{"admiral", "general"}, isAllowed: always, species: sAlways},
+	{name: "Unimaginative", cost: -1, classes: []string{"admiral", "general"}, isAllowed: always, species: sAlways},
+	// general
+	{name: "Unyielding", cost: 1, classes: []string{"general"}, isAllowed: always, species: sAlways},
+	{name: "Army Logistician", cost: 1, classes: []string{"general"}, isAllowed: always, species: sAlways},
+	{name: "Glory Seeker", cost: 1, classes: []string{"general"}, isAllowed: normalAuth(), species: sAlways},
+	{name: "Butcher", cost: 1, classes: []string{"general"}, isAllowed: excludeEthic("Pacifist", "Fanatic Pacifist"), species: sAlways},
+	// every class
+	{name: "Resilient", cost: 1, classes: leaderClasses, isAllowed: always, species: biological, excludes: []string{"Substance Abuser"}},
+	{name: "Lethargic", cost: -1, classes: leaderClasses, isAllowed: always, species: sAlways},
+	{name: "Substance Abuser", cost: -1, classes: leaderClasses, isAllowed: normalAuth(), species: biological, excludes: []string{"Resilient"}},
+	{name: "Arrested Development", cost: -1, classes: leaderClasses, isAllowed: always, species: andS(biological, excludeTrait("Venerable", "Enduring"))},
+}
+
+// generateLeaders rolls the starting leaders from the name seed of the empire, so a share code brings back the same roster.
+func generateLeaders(empire Empire) []Leader {
+	rng := rand.New(rand.NewSource(empire.nameSeed + 1))
+	res := []Leader{}
+	for _, class := range leaderClasses {
+		res = append(res, Leader{class: class, name: leaderName(rng, empire), traits: rollLeaderTraits(rng, empire, class)})
+	}
+	return res
+}
+
+// rollLeaderTraits draws positive traits until the points of the class are spent,
+// sometimes taking a negative trait first to pay for one more.
+func rollLeaderTraits(rng *rand.Rand, empire Empire, class string) []LeaderTrait {
+	pool := leaderTraitPool(empire, class)
+	for try := 0; try < maxLeaderTries; try++ {
+		traits := []LeaderTrait{}
+		points := leaderTraitPoints[class]
+		negatives := []LeaderTrait{}
+		for _, trait := range pool {
+			if trait.cost < 0 {
+				negatives = append(negatives, trait)
+			}
+		}
+		if len(negatives) > 0 && rng.Intn(4) == 0 {
+			trait := negatives[rng.Intn(len(negatives))]
+			traits = append(traits, trait)
+			points -= trait.cost
+		}
+		for points > 0 && len(traits) < maxLeaderTraits {
+			candidates := []LeaderTrait{}
+			for _, trait := range pool {
+				if trait.cost > 0 && trait.cost <= points && leaderTraitProblem(trait, traits) == "" {
+					candidates = append(candidates, trait)
+				}
+			}
+			if len(candidates) == 0 {
+				break
+			}
+			trait := candidates[rng.Intn(len(candidates))]
+			traits = append(traits, trait)
+			points -= trait.cost
+		}
+		if points == 0 {
+			return traits
+		}
+	}
+	return fallbackLeaderTraits(pool, leaderTraitPoints[class])
+}
+
+// fallbackLeaderTraits spends the points on the first positive traits of the pool that fit, for when every
+// try of rollLeaderTraits failed. Every class has traits costing one point that any empire may take, so
+// this spends all points.
+func fallbackLeaderTraits(pool []LeaderTrait, points int) []LeaderTrait {
+	traits := []LeaderTrait{}
+	for _, trait := range pool {
+		if trait.cost > 0 && trait.cost <= points && len(traits) < maxLeaderTraits && leaderTraitProblem(trait, traits) == "" {
+			traits = append(traits, trait)
+			points -= trait.cost
+		}
+	}
+	return traits
+}
+
+// leaderTraitPool lists the traits the class may have in the empire.
+func leaderTraitPool(empire Empire, class string) []LeaderTrait {
+	res := []LeaderTrait{}
+	for _, trait := range allLeaderTraits {
+		if contains(trait.classes, class) && trait.isAllowed.test(empire) && trait.species.test(empire.mainSpecies) {
+			res = append(res, trait)
+		}
+	}
+	return res
+}
+
+// leaderTraitProblem explains why the trait cannot be added to the others, or returns an empty string.
+func leaderTraitProblem(trait LeaderTrait, others []LeaderTrait) string {
+	for _, other := range others {
+		if other.name == trait.name {
+			return "already chosen"
+		}
+		if contains(trait.excludes, other.name) {
+			return "excluded by trait " + other.name
+		}
+	}
+	return ""
+}
+
+// leaderName follows the naming of the species: machines get designations and hive drones a node number.
+func leaderName(rng *rand.Rand, empire Empire) string {
+	sounds := phoneticsOf(empire.mainSpecies.popType)
+	switch {
+	case empire.mainSpecies.popType == "Machine":
+		return strings.ToUpper(word(rng, sounds, 1)) + fmt.Sprintf("-%d", 100+rng.Intn(900))
+	case empire.authority == "Hive Mind":
+		return word(rng, sounds, 2) + fmt.Sprintf(" Node %d", 1+rng.Intn(99))
+	}
+	return word(rng, sounds, 1+rng.Intn(2)) + " " + word(rng, sounds, 2)
+}
+
+func (l Leader) String() string {
+	return strings.ToUpper(l.class[:1]) + l.class[1:] + ": " + l.name + " (" + strings.Join(leaderTraitNames(l.traits), ", ") + ")"
+}
+
+func leaderTraitNames(traits []LeaderTrait) []string {
+	res := []string{}
+	for _, trait := range traits {
+		res = append(res, trait.name)
+	}
+	return res
+}
@@ -0,0 +1,329 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/gorilla/websocket"
+	"github.com/maxence-charriere/go-app/v9/pkg/app"
+)
+
+// lobby is a group of players that share one set of generated empires. The server
+// owns the lobby and sends its state to every member after each change.
+type lobby struct {
+	Code     string        `json:"code"`
+	Host     string        `json:"host"`
+	Members  []string      `json:"members"`
+	Settings lobbySettings `json:"settings"`
+	Empires  []Empire      `json:"empires"`
+	Error    string        `json:"error,omitempty"`
+	members  []*member
+	host     *member
+}
+
+// member is one connection to a lobby. Names are unique within a lobby, Members and Host list them.
+type member struct {
+	name string
+	conn *websocket.Conn // nil until the upgrade is done
+	send chan []byte     // lobby states for write to send
+}
+
+// memberQueue is the number of lobby states a member may fall behind before the server drops it.
+const memberQueue = 16
+
+// writeWait bounds the time a single write to a member may take.
+const writeWait = 10 * time.Second
+
+// write sends the queued lobby states to the member until the queue is closed.
+func (m *member) write() {
+	for data := range m.send {
+		m.conn.SetWriteDeadline(time.Now().Add(writeWait))
+		if err := m.conn.WriteMessage(websocket.TextMessage, data); err != nil {
+			m.conn.Close()
+		}
+	}
+}
+
+func (l *lobby) member(name string) *member {
+	for _, m := range l.members {
+		if m.name == name {
+			return m
+		}
+	}
+	return nil
+}
+
+// join adds the member, who hosts the lobby if it is the first one.
+func (l *lobby) join(m *member) {
+	l.members = append(l.members, m)
+	if l.host == nil {
+		l.host = m
+	}
+	l.updateMembers()
+}
+
+// leave removes the member, handing the lobby to the longest present member if it was the host.
+func (l *lobby) leave(m *member) {
+	for i, other := range l.members {
+		if other == m {
+			l.members = append(l.members[:i], l.members[i+1:]...)
+			break
+		}
+	}
+	if l.host == m {
+		l.host = nil
+		if len(l.members) > 0 {
+			l.host = l.members[0]
+		}
+	}
+	l.updateMembers()
+}
+
+func (l *lobby) updateMembers() {
+	l.Members = []string{}
+	for _, m := range l.members {
+		l.Members = append(l.Members, m.name)
+	}
+	l.Host = ""
+	if l.host != nil {
+		l.Host = l.host.name
+	}
+}
+
+// lobbySettings are the generation options the host picks for everyone.
+type lobbySettings struct {
+	Players         string   `json:"players"`
+	Count           int      `json:"count"`
+	UniqueOrigin    bool     `json:"uniqueOrigin"`
+	UniqueAuthority bool     `json:"uniqueAuthority"`
+	UniqueCivics    bool     `json:"uniqueCivics"`
+	Diverse         bool     `json:"diverse"`
+	Leftover        bool     `json:"leftover"`
+	FanaticChance   int      `json:"fanaticChance"` // percent
+	PredictCivic    bool     `json:"predictCivic"`
+	Order           string   `json:"order"`       // see parseOrder
+	GameVersion     string   `json:"gameVersion"` // see resolveVersion
+	MissingDLCs     []string `json:"missingDlcs"` // DLCs the players lack, see dlcNames
+}
+
+// lobbyMessage is sent by a member. Only the host may configure, anyone may reroll.
+type lobbyMessage struct {
+	Type     string         `json:"type"` // configure or reroll
+	Settings *lobbySettings `json:"settings,omitempty"`
+}
+
+// validate reports settings a lobby cannot roll with, the server keeps the settings before them.
+func (s lobbySettings) validate() error {
+	if _, err := parseFanaticPercent(s.FanaticChance); err != nil {
+		return err
+	}
+	if s.Count > maxPlayers {
+		return fmt.Errorf("%d empires are more than the %d a lobby rolls", s.Count, maxPlayers)
+	}
+	if players := playerList(s.Players, s.Count); len(players) > maxPlayers {
+		return fmt.Errorf("%d players are more than the %d a lobby rolls for", len(players), maxPlayers)
+	}
+	return checkDLCs(s.MissingDLCs)
+}
+
+func (l *lobby) reroll() {
+	opts := batchOptions{
+		unique:   uniqueness{origin: l.Settings.UniqueOrigin, authority: l.Settings.UniqueAuthority, civics: l.Settings.UniqueCivics},
+		diverse:  l.Settings.Diverse,
+		leftover: l.Settings.Leftover,
+		predict:  l.Settings.PredictCivic,
+		version:  l.Settings.GameVersion,
+		missing:  l.Settings.MissingDLCs,
+	}
+	fanatic, err := parseFanaticPercent(l.Settings.FanaticChance)
+	if err != nil {
+		l.Error = err.Error()
+		return
+	}
+	order, shuffle, err := parseOrder(l.Settings.Order)
+	if err != nil {
+		l.Error = err.Error()
+		return
+	}
+	opts.fanatic, opts.order, opts.shuffle = fanatic, order, shuffle
+	empires, err := generateBatch(playerList(l.Settings.Players, l.Settings.Count), opts)
+	l.Empires = empires
+	l.Error = ""
+	if err != nil {
+		l.Error = err.Error()
+	}
+}
+
+// lobbyView joins a lobby on a lobby server, see the serve command.
+type lobbyView struct {
+	app.Compo
+	server    string
+	name      string
+	code      string
+	socket    app.Value
+	onMessage app.Func
+	onClose   app.Func
+	state     *lobby
+	settings  lobbySettings
+	message   string
+}
+
+func (v *lobbyView) OnMount(ctx app.Context) {
+	v.server = app.Window().Get("location").Get("host").String()
+	if v.server == "" || app.Window().Get("location").Get("protocol").String() == "https:" {
+		v.server = "localhost:8080"
+	}
+	v.settings.Count = 3
+	v.settings.FanaticChance = defaultFanaticChance * 100
+}
+
+func (v *lobbyView) OnDismount() {
+	v.disconnect()
+}
+
+func (v *lobbyView) Render() app.UI {
+	return app.Div().Body(
+		app.A().Href("/").Text("Random empires"),
+		app.Br(),
+		app.Label().Text("Server:").For("server"),
+		app.Input().ID("server").Value(v.server).OnChange(v.ValueTo(&v.server)),
+		app.Label().Text("Name:").For("name"),
+		app.Input().ID("name").Value(v.name).OnChange(v.ValueTo(&v.name)),
+		app.Label().Text("Lobby code:").For("code"),
+		app.Input().ID("code").Placeholder("empty to host a new lobby").Value(v.code).OnChange(v.ValueTo(&v.code)),
+		app.Button().Text("Join").OnClick(v.join),
+		app.If(v.message != "", app.Span().Text(v.message)),
+		app.If(v.state != nil, v.renderLobby()),
+	)
+}
+
+func (v *lobbyView) renderLobby() app.UI {
+	l := v.state
+	if l == nil {
+		return app.Text("")
+	}
+	return app.Div().Body(
+		app.H3().Text("Lobby "+l.Code),
+		app.Span().Text("Host: "+l.Host),
+		app.Ul().Body(app.Range(l.Members).Slice(func(i int) app.UI {
+			return app.Li().Text(l.Members[i])
+		})),
+		app.If(l.Host == v.name, app.Div().Body(
+			app.Label().Text("Players:").For("players"),
+			app.Input().ID("players").Placeholder("comma separated names").Value(v.settings.Players).OnChange(v.ValueTo(&v.settings.Players)),
+			app.Label().Text("Empires:").For("count"),
+			app.Input().ID("count").Type("number").Min(1).Max(maxPlayers).Value(v.settings.Count).OnChange(v.ValueTo(&v.settings.Count)),
+			app.Br(),
+			app.Label().Text("Unique:"),
+			checkbox("lobbyUniqueOrigin", "Origin", &v.settings.UniqueOrigin),
+			checkbox("lobbyUniqueAuthority", "Authority", &v.settings.UniqueAuthority),
+			checkbox("lobbyUniqueCivics", "Civics", &v.settings.UniqueCivics),
+			checkbox("lobbyDiverse", "Diverse playstyles", &v.settings.Diverse),
+			checkbox("lobbyLeftover", "Leftover trait points", &v.settings.Leftover),
+			checkbox("lobbyPredictCivic", "Predict reform civic", &v.settings.PredictCivic),
+			app.Label().Text("Start with:").For("lobbyOrder"),
+			orderSelect("lobbyOrder", &v.settings.Order),
+			app.Label().Text("Game version:").For("lobbyVersion"),
+			versionSelect("lobbyVersion", &v.settings.GameVersion),
+			app.Br(),
+			app.Label().Text("Without DLC:"),
+			app.Range(dlcNames()).Slice(func(i int) app.UI {
+				return v.dlcCheckbox(dlcNames()[i])
+			}),
+			app.Br(),
+			app.Label().Text("Fanatic ethics %:").For("lobbyFanatic"),
+			app.Input().ID("lobbyFanatic").Type("number").Min(0).Max(100).Value(v.settings.FanaticChance).OnChange(v.ValueTo(&v.settings.FanaticChance)),
+			app.Button().Text("Apply").OnClick(v.configure),
+		)),
+		app.Button().Text("Reroll").OnClick(v.reroll),
+		app.If(l.Error != "", app.Span().Text(l.Error)),
+		app.Div().Class("horizontal").Body(app.Range(l.Empires).Slice(func(i int) app.UI {
+			return renderEmpire(l.Empires[i])
+		})),
+	)
+}
+
+// dlcCheckbox marks a DLC the players lack, so no empire needs it.
+func (v *lobbyView) dlcCheckbox(dlc string) app.UI {
+	id := "lobbyWithout" + strings.ReplaceAll(dlc, " ", "")
+	return app.Span().Body(
+		app.Input().Type("checkbox").ID(id).Checked(contains(v.settings.MissingDLCs, dlc)).OnChange(func(ctx app.Context, e app.Event) {
+			v.settings.MissingDLCs = withoutNames(v.settings.MissingDLCs, []string{dlc})
+			if ctx.JSSrc().Get("checked").Bool() {
+				v.settings.MissingDLCs = append(v.settings.MissingDLCs, dlc)
+			}
+		}),
+		app.Label().For(id).Text(dlc),
+	)
+}
+
+func (v *lobbyView) join(ctx app.Context, e app.Event) {
+	v.disconnect()
+	query := url.Values{"name": {v.name}, "code": {v.code}}
+	socket := app.Window().Get("WebSocket").New("ws://" + v.server + "/ws?" + query.Encode())
+	onMessage := app.FuncOf(func(this app.Value, args []app.Value) interface{} {
+		state := &lobby{}
+		if err := json.Unmarshal([]byte(args[0].Get("data").String()), state); err != nil {
+			app.Log(err)
+			return nil
+		}
+		ctx.Dispatch(func(ctx app.Context) {
+			if v.state == nil {
+				v.settings = state.Settings
+			}
+			v.state = state
+			v.code = state.Code
+			v.message = ""
+		})
+		return nil
+	})
+	onClose := app.FuncOf(func(this app.Value, args []app.Value) interface{} {
+		ctx.Dispatch(func(ctx app.Context) {
+			v.state = nil
+			v.message = "Disconnected: " + args[0].Get("reason").String()
+		})
+		return nil
+	})
+	socket.Call("addEventListener", "message", onMessage)
+	socket.Call("addEventListener", "close", onClose)
+	v.socket = socket
+	v.onMessage = onMessage
+	v.onClose = onClose
+}
+
+func (v *lobbyView) disconnect() {
+	if v.socket == nil {
+		return
+	}
+	v.socket.Call("removeEventListener", "message", v.onMessage)
+	v.socket.Call("removeEventListener", "close", v.onClose)
+	v.socket.Call("close")
+	v.onMessage.Release()
+	v.onClose.Release()
+	v.socket = nil
+	v.state = nil
+}
+
+func (v *lobbyView) configure(ctx app.Context, e app.Event) {
+	settings := v.settings
+	v.send(lobbyMessage{Type: "configure", Settings: &settings})
+}
+
+func (v *lobbyView) reroll(ctx app.Context, e app.Event) {
+	v.send(lobbyMessage{Type: "reroll"})
+}
+
+func (v *lobbyView) send(msg lobbyMessage) {
+	if v.socket == nil {
+		return
+	}
+	data, err := json.Marshal(msg)
+	if err != nil {
+		app.Log(err)
+		return
+	}
+	v.socket.Call("send", string(data))
+}
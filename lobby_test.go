@@ -0,0 +1,30 @@
+package main
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestLobbySettingsValidate(t *testing.T) {
+	tests := []struct {
+		name     string
+		settings lobbySettings
+		err      string
+	}{
+		{"defaults", lobbySettings{Count: 3, FanaticChance: 25}, ""},
+		{"no count", lobbySettings{}, ""},
+		{"most empires", lobbySettings{Count: maxPlayers}, ""},
+		{"too many empires", lobbySettings{Count: maxPlayers + 1}, "empires are more than"},
+		{"names over count", lobbySettings{Count: maxPlayers + 1, Players: "a,b"}, "empires are more than"},
+		{"too many players", lobbySettings{Players: strings.Repeat("p,", maxPlayers+1)}, "players are more than"},
+		{"fanatic chance", lobbySettings{FanaticChance: 101}, "fanatic chance"},
+		{"missing DLC", lobbySettings{MissingDLCs: []string{"Toxoids", "Overlord"}}, ""},
+		{"unknown DLC", lobbySettings{MissingDLCs: []string{"Toxoids", "Nemesis 2"}}, "unknown DLC"},
+	}
+	for _, test := range tests {
+		err := test.settings.validate()
+		if test.err == "" && err != nil || test.err != "" && (err == nil || !strings.Contains(err.Error(), test.err)) {
+			t.Errorf("%s: got error %v, want one containing %q", test.name, err, test.err)
+		}
+	}
+}
@@ -3,6 +3,8 @@ package main
 import (
 	"fmt"
 	"math/rand"
+	"os"
+	"strings"
 	"time"
 
 	"github.com/maxence-charriere/go-app/v9/pkg/app"
@@ -12,92 +14,342 @@ var seed = time.Now().UnixNano()
 var r = rand.New(rand.NewSource(seed))
 
 func main() {
-	fmt.Println("Seed is " + fmt.Sprint(seed))
 	app.Route("/", &data{})
+	app.Route("/builder", &builder{})
+	app.Route("/draft", &draftView{})
+	app.Route("/lobby", &lobbyView{})
+	app.Route("/catalogue", &catalogueView{})
+	app.RouteWithRegexp("^/catalogue/.+", &catalogueItemView{})
+	app.Route("/matrix", &matrixView{})
 	app.RunWhenOnBrowser()
-	err := app.GenerateStaticWebsite("docs", &app.Handler{
+	if len(os.Args) > 1 {
+		switch os.Args[1] {
+		case "stats":
+			runStats(os.Args[2:])
+			return
+		case "generate":
+			runGenerate(os.Args[2:])
+			return
+		case "serve":
+			runServe(os.Args[2:])
+			return
+		case "decode":
+			runDecode(os.Args[2:])
+			return
+		case "diff":
+			runDiff(os.Args[2:])
+			return
+		case "graph":
+			runGraph(os.Args[2:])
+			return
+		case "matrix":
+			runMatrix(os.Args[2:])
+			return
+		}
+	}
+	fmt.Println("Seed is " + fmt.Sprint(seed))
+	err := app.GenerateStaticWebsite("docs", appHandler(app.GitHubPages("stellaris-empire-generator")), cataloguePages()...)
+	if err != nil {
+		panic(err)
+	}
+}
+
+func appHandler(resources app.ResourceProvider) *app.Handler {
+	return &app.Handler{
 		Name:        "Stellaris",
 		Description: "A Stellaris Empire Generator",
 		Styles: []string{
 			"/web/app.css",
 		},
-		Resources: app.GitHubPages("stellaris-empire-generator"),
-	})
-	if err != nil {
-		panic(err)
+		Resources: resources,
 	}
 }
 
 func (d *data) Render() app.UI {
 	return app.Div().Body(
+		app.A().Href("/builder").Text("Empire builder"),
+		app.Text(" "),
+		app.A().Href("/draft").Text("Draft"),
+		app.Text(" "),
+		app.A().Href("/lobby").Text("Lobby"),
+		app.Text(" "),
+		app.A().Href("/catalogue").Text("Catalogue"),
+		app.Text(" "),
+		app.A().Href("/matrix").Text("Compatibility matrix"),
+		app.Br(),
+		app.Label().Text("Players:").For("players"),
+		app.Input().ID("players").Placeholder("comma separated names").Value(d.Players).OnChange(d.ValueTo(&d.Players)),
+		app.Label().Text("Empires:").For("count"),
+		app.Input().ID("count").Type("number").Min(1).Value(d.Count).OnChange(d.ValueTo(&d.Count)),
+		app.Br(),
+		app.Label().Text("Unique:"),
+		checkbox("uniqueOrigin", "Origin", &d.UniqueOrigin),
+		checkbox("uniqueAuthority", "Authority", &d.UniqueAuthority),
+		checkbox("uniqueCivics", "Civics", &d.UniqueCivics),
+		checkbox("diverse", "Diverse playstyles", &d.Diverse),
+		checkbox("leftover", "Leftover trait points", &d.Leftover),
+		checkbox("predictCivic", "Predict reform civic", &d.PredictCivic),
+		app.Label().Text("Start with:").For("order"),
+		orderSelect("order", &d.Order),
+		app.Label().Text("Game version:").For("version"),
+		versionSelect("version", &d.Version),
+		app.Label().Text("Fanatic ethics %:").For("fanatic"),
+		app.Input().ID("fanatic").Type("number").Min(0).Max(100).Value(d.FanaticChance).OnChange(d.ValueTo(&d.FanaticChance)),
+		app.Br(),
 		app.Button().Text("Generate").OnClick(d.generateEmpire),
+		app.Label().Text("Share code:").For("code"),
+		app.Input().ID("code").Value(d.Code).OnChange(d.ValueTo(&d.Code)),
+		app.Button().Text("Load").OnClick(d.loadEmpire),
+		app.If(d.Error != "", app.Span().Text(d.Error)),
 		app.Div().Class("horizontal").Body(
 			app.Range(d.Empires).Slice(func(i int) app.UI {
 				return app.Div().Body(
-					app.Label().Text("Authority:").For("authority"),
-					app.Span().ID("authority").Text(d.Empires[i].authority),
-					app.Br(),
-					app.Label().Text("Ethics:").For("ethics"),
-					app.Span().ID("ethics").Text(d.Empires[i].ethics),
-					app.Br(),
-					app.Label().Text("Civics:").For("civics"),
-					app.Span().ID("civics").Text(d.Empires[i].civics),
-					app.Br(),
-					app.Label().Text("Origin:").For("origin"),
-					app.Span().ID("origin").Text(d.Empires[i].origin.name),
-					app.Br(),
-					app.Label().Text("Planet Class:").For("planet"),
-					app.Span().ID("planet").Text(d.Empires[i].homeplanet),
-					app.Br(),
-					app.Div().Body(
-						app.Span().Text("Main Species:"),
-						app.Br(),
-						app.Label().Text("Type").For("MainType"),
-						app.Span().ID("MainType").Text(d.Empires[i].mainSpecies.popType),
-						app.Ul().Body(app.Range(d.Empires[i].mainSpecies.traits).Slice(func(j int) app.UI {
-							trait := d.Empires[i].mainSpecies.traits[j]
-							return app.Li().Text(trait.name)
-						})),
-						app.If(len(d.Empires[i].subSpecies.traits) > 0, app.Div().Body(
-							app.Span().Text("Sub Species:"),
-							app.Br(),
-							app.Label().Text("Type").For("SubType"),
-							app.Span().ID("SubType").Text(d.Empires[i].subSpecies.popType),
-							app.Ul().Body(app.Range(d.Empires[i].subSpecies.traits).Slice(func(j int) app.UI {
-								trait := d.Empires[i].subSpecies.traits[j]
-								return app.Li().Text(trait.name)
-							})),
-						)),
-					),
-					app.Br(),
-					app.Br(),
+					renderEmpire(d.Empires[i]),
+					app.If(d.Empires[i].odds.mainTraits == nil && d.Empires[i].mainBase.popType != "", app.Button().Text("Trait odds").OnClick(d.showTraitOdds(i))),
 				)
 			}),
 		))
 }
 
+func renderEmpire(empire Empire) app.UI {
+	n := generateNames(empire)
+	leaders := generateLeaders(empire)
+	return app.Div().Body(
+		app.If(empire.player != "", app.H3().Text(empire.player)),
+		app.H4().Text(n.empire),
+		app.Label().Text("Government:").For("government"),
+		app.Span().ID("government").Text(governmentName(empire)),
+		app.Br(),
+		app.Label().Text("Authority:").For("authority"),
+		app.Span().ID("authority").Text(withChance(empire.authority, empire.odds.authority)),
+		app.Br(),
+		app.Label().Text("Ethics:").For("ethics"),
+		app.Span().ID("ethics").Text(empire.ethicsText()),
+		app.Br(),
+		app.Label().Text("Civics:").For("civics"),
+		app.Span().ID("civics").Text(empire.civicsText()),
+		app.Br(),
+		app.Label().Text("Civic slots:").For("civicSlots"),
+		app.Span().ID("civicSlots").Text(civicSlotsText(empire)),
+		app.Br(),
+		app.If(empire.predicted.name != "", app.Div().Body(
+			app.Label().Text("Predicted civic:").For("predicted"),
+			app.Span().ID("predicted").Text(withChance(empire.predicted.name, empire.odds.predicted)),
+		)),
+		app.Label().Text("Origin:").For("origin"),
+		app.Span().ID("origin").Text(withChance(empire.origin.name, empire.odds.origin)),
+		app.Br(),
+		app.Label().Text("Homeworld:").For("homeworld"),
+		app.Span().ID("homeworld").Text(n.homeworld),
+		app.Br(),
+		app.Label().Text("Planet Class:").For("planet"),
+		app.Span().ID("planet").Text(withChance(empire.homeplanet, empire.odds.homeplanet)),
+		app.Br(),
+		app.Div().Body(
+			app.Span().Text("Main Species: "+n.species+" ("+n.plural+", "+n.adjective+")"),
+			app.Br(),
+			app.Label().Text("Type").For("MainType"),
+			app.Span().ID("MainType").Text(empire.mainSpecies.popType),
+			app.Br(),
+			app.Label().Text("Habitability").For("MainHabitability"),
+			app.Span().ID("MainHabitability").Text(habitabilityText(empire, empire.mainSpecies)),
+			renderTraits(empire.mainSpecies, empire.odds.mainTraits),
+			app.If(empire.mainSpecies.pointsLeft() > 0, app.Span().Text(fmt.Sprintf("Trait points left: %d", empire.mainSpecies.pointsLeft()))),
+			app.If(len(empire.subSpecies.traits) > 0, app.Div().Body(
+				app.Span().Text(subSpeciesName(empire)+":"),
+				app.Br(),
+				app.Label().Text("Type").For("SubType"),
+				app.Span().ID("SubType").Text(empire.subSpecies.popType),
+				app.Br(),
+				app.Label().Text("Habitability").For("SubHabitability"),
+				app.Span().ID("SubHabitability").Text(habitabilityText(empire, empire.subSpecies)),
+				renderTraits(empire.subSpecies, empire.odds.subTraits),
+				app.If(empire.subSpecies.pointsLeft() > 0, app.Span().Text(fmt.Sprintf("Trait points left: %d", empire.subSpecies.pointsLeft()))),
+			)),
+		),
+		app.Span().Text("Leaders:"),
+		app.Ul().Body(app.Range(leaders).Slice(func(j int) app.UI {
+			return app.Li().Text(leaders[j].String())
+		})),
+		renderTrace(empire.trace),
+		app.Label().Text("Game version:").For("gameVersion"),
+		app.Span().ID("gameVersion").Text(empire.gameVersion()),
+		app.Br(),
+		app.Label().Text("Share code:"),
+		app.Input().ReadOnly(true).Value(shareCode(empire)),
+		app.Br(),
+		app.Br(),
+	)
+}
+
+// renderTraits lists the traits of a species, with the overtuned traits and their lifespan malus on their own.
+func renderTraits(species Species, chances map[string]float64) app.UI {
+	regular, overtuned := splitOvertuned(species.traits)
+	return app.Div().Body(
+		app.Ul().Body(app.Range(regular).Slice(func(j int) app.UI {
+			return app.Li().Text(withChance(regular[j].name, chances[regular[j].name]))
+		})),
+		app.If(len(overtuned) > 0, app.Div().Body(
+			app.Span().Text(fmt.Sprintf("Overtuned traits (leader lifespan %d years):", lifespanMalus(species))),
+			app.Ul().Body(app.Range(overtuned).Slice(func(j int) app.UI {
+				return app.Li().Text(withChance(overtuned[j].name, chances[overtuned[j].name]))
+			})),
+		)),
+	)
+}
+
+// orderSelect offers the generation orders people ask for most, see parseOrder for the full syntax.
+func orderSelect(id string, value *string) app.UI {
+	options := []string{"", "origin", "civics", "authority", randomOrder}
+	return app.Select().ID(id).OnChange(func(ctx app.Context, e app.Event) {
+		*value = ctx.JSSrc().Get("value").String()
+	}).Body(app.Range(options).Slice(func(i int) app.UI {
+		text := options[i]
+		switch text {
+		case "":
+			text = "ethics"
+		case randomOrder:
+			text = "random step"
+		}
+		return app.Option().Value(options[i]).Text(text).Selected(options[i] == *value)
+	}))
+}
+
+// versionSelect offers the catalogue versions, the empty value follows the latest one.
+func versionSelect(id string, value *string) app.UI {
+	options := append([]string{""}, versionNames()...)
+	return app.Select().ID(id).OnChange(func(ctx app.Context, e app.Event) {
+		*value = ctx.JSSrc().Get("value").String()
+	}).Body(app.Range(options).Slice(func(i int) app.UI {
+		text := options[i]
+		if text == "" {
+			text = latestVersion + " (" + catalogueVersions[0].name + ")"
+		}
+		return app.Option().Value(options[i]).Text(text).Selected(options[i] == *value)
+	}))
+}
+
+func checkbox(id string, text string, value *bool) app.UI {
+	return app.Span().Body(
+		app.Input().Type("checkbox").ID(id).Checked(*value).OnChange(func(ctx app.Context, e app.Event) {
+			*value = ctx.JSSrc().Get("checked").Bool()
+		}),
+		app.Label().For(id).Text(text),
+	)
+}
+
 type data struct {
 	app.Compo
-	Empires []Empire
+	Empires         []Empire
+	Players         string
+	Count           int
+	UniqueOrigin    bool
+	UniqueAuthority bool
+	UniqueCivics    bool
+	Diverse         bool
+	Leftover        bool
+	FanaticChance   int // percent
+	PredictCivic    bool
+	Order           string // see parseOrder
+	Version         string // see resolveVersion
+	Code            string
+	Error           string
+}
+
+func (d *data) OnInit() {
+	d.Count = 3
+	d.FanaticChance = defaultFanaticChance * 100
 }
 
 func (d *data) generateEmpire(ctx app.Context, e app.Event) {
-	d.Empires = []Empire{}
-	for i := 0; i < 3; i++ {
-		empire := Empire{}
-		empire = chooseEthic(empire)
-		empire = chooseAuthority(empire)
-		empire = chooseCivic(empire)
-		empire = chooseCivic(empire)
-		empire = chooseOrigin(empire)
-		empire = chooseHomeplanet(empire)
-		empire = generateSpecies(empire)
-		d.Empires = append(d.Empires, empire)
+	order, shuffle, err := parseOrder(d.Order)
+	if err != nil {
+		d.Error = err.Error()
+		return
+	}
+	fanatic, err := parseFanaticPercent(d.FanaticChance)
+	if err != nil {
+		d.Error = err.Error()
+		return
+	}
+	opts := batchOptions{
+		unique:   uniqueness{origin: d.UniqueOrigin, authority: d.UniqueAuthority, civics: d.UniqueCivics},
+		diverse:  d.Diverse,
+		traced:   true,
+		leftover: d.Leftover,
+		fanatic:  fanatic,
+		predict:  d.PredictCivic,
+		order:    order,
+		shuffle:  shuffle,
+		version:  d.Version,
+	}
+	empires, err := generateBatch(playerList(d.Players, d.Count), opts)
+	d.Empires = empires
+	d.Error = ""
+	if err != nil {
+		d.Error = err.Error()
+	}
+}
+
+// showTraitOdds estimates the trait odds of one card, only on demand as that takes a few thousand rolls.
+func (d *data) showTraitOdds(i int) app.EventHandler {
+	return func(ctx app.Context, e app.Event) {
+		d.Empires[i] = estimateTraitOdds(d.Empires[i])
 	}
 }
 
+// loadEmpire shows the empire of the entered share code instead of generating new ones.
+func (d *data) loadEmpire(ctx app.Context, e app.Event) {
+	empire, err := decodeShareCode(d.Code)
+	d.Error = ""
+	if err != nil {
+		d.Error = err.Error()
+		return
+	}
+	d.Empires = []Empire{empire}
+}
+
+// maxEmpireTries bounds the retries of the generation steps, so an order or preset that can never
+// agree ends in an error instead of a hang.
+const maxEmpireTries = 1000
+
+// fillEmpire runs every generation step on the given empire, which may carry a trace to record into.
+// The steps in generationSteps are retried until they agree, only the accepted try ends up in the trace.
+// A random order is drawn once, so every try of the empire runs the steps in the same order.
+func fillEmpire(empire Empire) (Empire, error) {
+	order := stepOrder(empire)
+	ok := false
+	for try := 0; try < maxEmpireTries && !ok; try++ {
+		attempt := empire
+		if empire.trace != nil {
+			attempt.trace = &trace{}
+		}
+		var result Empire
+		if result, ok = runSteps(attempt, order); ok {
+			if empire.trace != nil {
+				empire.trace.steps = append(empire.trace.steps, result.trace.steps...)
+				result.trace = empire.trace
+			}
+			empire = result
+		}
+	}
+	if !ok {
+		return empire, fmt.Errorf("the generation steps %s did not agree after %d tries", strings.Join(order, ", "), maxEmpireTries)
+	}
+	empire = fillCivicSlots(empire) // the origin may open more slots
+	empire = predictCivic(empire)
+	empire, err := generateSpecies(empire)
+	if err != nil {
+		return empire, err
+	}
+	empire = chooseHomeplanet(empire)
+	empire.nameSeed = r.Int63()
+	return empire, nil
+}
+
 func (e Empire) String() string {
-	res := e.authority + "\nEthics: "
+	n := generateNames(e)
+	res := n.empire + "\n" + governmentName(e) + " (" + e.authority + ")\nEthics: "
 	for _, ethic := range e.ethics {
 		res += ethic.name + " "
 	}
@@ -105,233 +357,575 @@ func (e Empire) String() string {
 	for _, civic := range e.civics {
 		res += civic.name + " "
 	}
+	res += "\nCivic slots: " + civicSlotsText(e)
+	if e.predicted.name != "" {
+		res += "\nPredicted civic: " + e.predicted.name
+	}
 	res += "\nOrigin: " + e.origin.name
-	res += "\nPlanet: " + e.homeplanet
+	res += "\nSpecies: " + n.species + " (" + n.plural + ", " + n.adjective + ")"
+	res += "\nHomeworld: " + n.homeworld + " (" + e.homeplanet + ")"
+	res += "\nHabitability: " + habitabilityText(e, e.mainSpecies)
+	if _, overtuned := splitOvertuned(e.mainSpecies.traits); len(overtuned) > 0 {
+		res += fmt.Sprintf("\nOvertuned traits: %s (leader lifespan %d years)", strings.Join(traitNames(overtuned), ", "), lifespanMalus(e.mainSpecies))
+	}
+	if e.mainSpecies.pointsLeft() > 0 {
+		res += fmt.Sprintf("\nTrait points left: %d", e.mainSpecies.pointsLeft())
+	}
+	if e.subSpecies.popType != "" {
+		res += "\n" + subSpeciesName(e) + ": " + e.subSpecies.popType + " (" + strings.Join(traitNames(e.subSpecies.traits), ", ") + "), " + habitabilityText(e, e.subSpecies)
+	}
+	for _, leader := range generateLeaders(e) {
+		res += "\n" + leader.String()
+	}
+	res += "\nGame version: " + e.gameVersion()
+	res += "\nShare code: " + shareCode(e)
 	return res
 }
 
 func chooseAuthority(empire Empire) Empire {
+	step := empire.trace.begin("chooseAuthority", "")
 	result := []Authority{}
-	for _, auth := range allAuthorities {
-		if auth.isAllowed(empire) {
-			result = append(result, auth)
+	for _, auth := range empire.catalogue().authorities {
+		candidate := empire
+		candidate.authority = auth.name
+		if problem := partialProblem(candidate); problem != "" {
+			step.exclude(auth.name, problem)
+			continue
 		}
+		result = append(result, auth)
+	}
+	if len(result) == 0 {
+		return empire
 	}
 	empire.authority = result[r.Intn(len(result))].name
+	empire.odds.authority = 1 / float64(len(result))
+	if step != nil {
+		for _, auth := range result {
+			step.Candidates = append(step.Candidates, auth.name)
+		}
+		step.Drawn = empire.authority
+	}
 	return empire
 }
 
 func chooseCivic(empire Empire) Empire {
-	civicList := getCivicList(empire)
+	step := empire.trace.begin("chooseCivic", fmt.Sprintf("civic %d", len(empire.civics)+1))
+	civicList := getCivicList(empire, step)
 	empire.civics = append(empire.civics, civicList[r.Intn(len(civicList))])
+	empire.odds.civics = append(empire.odds.civics, 1/float64(len(civicList)))
+	if step != nil {
+		for _, civic := range civicList {
+			step.Candidates = append(step.Candidates, civic.name)
+		}
+		step.Drawn = empire.civics[len(empire.civics)-1].name
+	}
 	return empire
 }
 
-func getCivicList(empire Empire) []Civic {
+func getCivicList(empire Empire, step *traceStep) []Civic {
 	result := []Civic{}
 outer:
-	for _, civic := range allCivics {
-		if civic.isAllowed(empire) {
-			for _, existing := range empire.civics {
-				if existing.name == civic.name {
-					continue outer
-				}
+	for _, civic := range empire.catalogue().civics {
+		for _, existing := range empire.civics {
+			if existing.name == civic.name {
+				step.exclude(civic.name, "already chosen")
+				continue outer
 			}
-			result = append(result, civic)
 		}
+		candidate := empire
+		candidate.civics = append(append([]Civic{}, empire.civics...), civic)
+		if problem := partialProblem(candidate); problem != "" {
+			step.exclude(civic.name, problem)
+			continue
+		}
+		result = append(result, civic)
 	}
 	return result
 }
 
+// chooseEthic draws ethics until their points add up to maxEthicPoints. Every draw picks a regular ethic,
+// which becomes fanatic with the fanatic chance of the empire if the points left allow it.
 func chooseEthic(empire Empire) Empire {
-	firstFanatic := r.Intn(2) == 1
-	ethicList := getEthicList(empire)
-	firstDraw := ethicList[r.Intn(len(ethicList))]
-	if firstDraw.name == "Gestalt Consciousness" {
-		empire.ethics = []Ethic{firstDraw}
-		return empire
-	}
-	if firstFanatic {
-		empire.ethics = append(empire.ethics, Ethic{name: "Fanatic " + firstDraw.name, isAllowed: firstDraw.isAllowed})
-		ethicList := getEthicList(empire)
-		nextDraw := ethicList[r.Intn(len(ethicList))]
-		empire.ethics = append(empire.ethics, nextDraw)
-	} else {
-		empire.ethics = append(empire.ethics, firstDraw)
-		for i := 0; i < 2; i++ {
-			ethicList := getEthicList(empire)
-			nextDraw := ethicList[r.Intn(len(ethicList))]
-			empire.ethics = append(empire.ethics, nextDraw)
+	for i := 1; ethicPoints(empire.ethics) < maxEthicPoints; i++ {
+		step := empire.trace.begin("chooseEthic", fmt.Sprintf("ethic %d", i))
+		candidates := getEthicList(empire, step)
+		regular := []Ethic{}
+		for _, ethic := range candidates {
+			if ethic.regular == "" {
+				regular = append(regular, ethic)
+			}
+		}
+		if len(regular) == 0 {
+			break
+		}
+		drawn := regular[r.Intn(len(regular))]
+		chance := drawChance(regular, drawn.name)
+		if fanatic, ok := fanaticOf(drawn, candidates); ok {
+			// both outcomes of the fanatic roll scale the chance of the drawn ethic
+			if r.Float64() < empire.fanaticChance {
+				drawn = fanatic
+				chance *= empire.fanaticChance
+			} else {
+				chance *= 1 - empire.fanaticChance
+			}
 		}
+		step.draw(ethicNames(regular), drawn.name)
+		empire.ethics = append(empire.ethics, drawn)
+		empire.odds.ethics = append(empire.odds.ethics, chance)
 	}
 	return empire
 }
 
-func getEthicList(empire Empire) []Ethic {
+// fanaticOf finds the fanatic form of a regular ethic among the candidates.
+func fanaticOf(ethic Ethic, candidates []Ethic) (Ethic, bool) {
+	for _, candidate := range candidates {
+		if candidate.regular == ethic.name {
+			return candidate, true
+		}
+	}
+	return Ethic{}, false
+}
+
+// getEthicList lists the ethics, in either form, that can join the ethics of the empire.
+func getEthicList(empire Empire, step *traceStep) []Ethic {
 	result := []Ethic{}
-outer:
+	left := maxEthicPoints - ethicPoints(empire.ethics)
 	for _, ethic := range allEthics {
-		if ethic.isAllowed(empire) {
-			for _, existing := range empire.ethics {
-				if existing.name == ethic.name || existing.name == "Fanatic "+ethic.name {
-					continue outer
-				}
-			}
+		candidate := empire
+		candidate.ethics = append(append([]Ethic{}, empire.ethics...), ethic)
+		switch {
+		case partialProblem(candidate) != "":
+			step.exclude(ethic.name, partialProblem(candidate))
+		case ethicAxisProblem(ethic, empire.ethics) != "":
+			step.exclude(ethic.name, ethicAxisProblem(ethic, empire.ethics))
+		case ethic.points > left:
+			step.exclude(ethic.name, fmt.Sprintf("needs %d ethic points, %d left", ethic.points, left))
+		default:
 			result = append(result, ethic)
 		}
 	}
 	return result
 }
 
+// ethicAxisProblem explains why an ethic clashes with the others on its axis, or returns an empty string.
+func ethicAxisProblem(ethic Ethic, others []Ethic) string {
+	for _, other := range others {
+		switch {
+		case other.axis != ethic.axis:
+		case other.regularName() == ethic.regularName():
+			return "already chosen"
+		default:
+			return "opposes " + other.name
+		}
+	}
+	return ""
+}
+
+func ethicNames(ethics []Ethic) []string {
+	res := []string{}
+	for _, ethic := range ethics {
+		res = append(res, ethic.name)
+	}
+	return res
+}
+
 func chooseOrigin(empire Empire) Empire {
+	if empire.origin.name != "" { // given in advance, such as by a draft card
+		return empire
+	}
+	step := empire.trace.begin("chooseOrigin", "")
 	result := []Origin{}
-	for _, origin := range allOrigins {
-		if origin.isAllowed(empire) {
-			result = append(result, origin)
+	for _, origin := range empire.catalogue().origins {
+		candidate := empire
+		candidate.origin = origin
+		if problem := partialProblem(candidate); problem != "" {
+			step.exclude(origin.name, problem)
+			continue
 		}
+		result = append(result, origin)
+	}
+	if len(result) == 0 {
+		return empire
 	}
 	empire.origin = result[r.Intn(len(result))]
+	empire.odds.origin = 1 / float64(len(result))
+	if step != nil {
+		for _, origin := range result {
+			step.Candidates = append(step.Candidates, origin.name)
+		}
+		step.Drawn = empire.origin.name
+	}
 	return empire
 }
 
-func chooseHomeplanet(empire Empire) Empire {
-	planets := []string{"Desert", "Arid", "Savanna", "Ocean", "Continental", "Tropical", "Arctic", "Alpine", "Tundra"}
-	empire.homeplanet = planets[r.Intn(len(planets))]
+// generateSpecies builds the species from the species rules of the civics, origin and authority, then fills in random traits.
+func generateSpecies(empire Empire) (Empire, error) {
+	rules := speciesRulesOf(empire)
+	species := mainSpeciesBase(empire, randomPopType(compatiblePopTypes(mainPopTypes(empire), grantedTraits(rules))))
+	empire.mainBase = species
+	var err error
+	if empire.mainSpecies, err = fillSpecies(species, empire.authority == "Hive Mind", empire.trace, "main species"); err != nil {
+		return empire, err
+	}
+	if template := subSpeciesTemplate(rules); template != nil {
+		empire.subBase = secondarySpecies(empire, rules, *template)
+		if empire.subSpecies, err = fillSpecies(empire.subBase, empire.authority == "Hive Mind", empire.trace, "sub species"); err != nil {
+			return empire, err
+		}
+	}
+	return empire, nil
+}
+
+// mainSpeciesBase starts the main species of the pop type with its budget and the traits the species rules grant.
+func mainSpeciesBase(empire Empire, popType string) Species {
+	species := budgetedSpecies(empire, popType)
+	for _, trait := range grantedTraits(speciesRulesOf(empire)) {
+		species = withTrait(species, trait.name)
+	}
+	return species
+}
+
+// grantedTraits lists the traits the rules force on the main species.
+func grantedTraits(rules []speciesRules) []Trait {
+	res := []Trait{}
+	for _, rule := range rules {
+		for _, name := range rule.traits {
+			res = append(res, originTraits[name])
+		}
+	}
+	return res
+}
+
+// budgetedSpecies starts a main species of the pop type with the budget the species rules of the empire add to its archetype.
+func budgetedSpecies(empire Empire, popType string) Species {
+	species := newSpecies(popType)
+	species.allowLeftover, species.version = empire.allowLeftover, empire.version
+	for _, rule := range speciesRulesOf(empire) {
+		species = species.withRules(rule)
+	}
+	return species
+}
+
+// withRules applies what a species rule does to every species of the empire.
+func (s Species) withRules(rule speciesRules) Species {
+	s = s.withBudget(rule.budget)
+	s.overtuned = s.overtuned || rule.overtuned
+	return s
+}
+
+// restoreBudgets gives the species of a decoded empire the budgets and trait pools they were generated with, so the points left add up again.
+func restoreBudgets(empire Empire) Empire {
+	rules := speciesRulesOf(empire)
+	main := budgetedSpecies(empire, empire.mainSpecies.popType)
+	empire.mainSpecies.initialTraitPoints, empire.mainSpecies.maxTraits, empire.mainSpecies.maxNegative = main.initialTraitPoints, main.maxTraits, main.maxNegative
+	empire.mainSpecies.overtuned, empire.mainSpecies.version = main.overtuned, empire.version
+	if template := subSpeciesTemplate(rules); template != nil && empire.subSpecies.popType != "" {
+		sub := newSpecies(empire.subSpecies.popType).withBudget(template.budget)
+		for _, rule := range rules {
+			sub = sub.withRules(rule)
+		}
+		empire.subSpecies.initialTraitPoints, empire.subSpecies.maxTraits, empire.subSpecies.maxNegative = sub.initialTraitPoints, sub.maxTraits, sub.maxNegative
+		empire.subSpecies.overtuned, empire.subSpecies.version = sub.overtuned, empire.version
+	}
 	return empire
 }
 
-func generateSpecies(empire Empire) Empire {
-	species := Species{}
-	generateSubSpecies := false
-	popTypes := []string{"Aquatic", "Mammalian", "Reptilian", "Avian", "Arthropoid", "Molluscoid", "Fungoid", "Plantoid", "Lithoid", "Necroid", "Toxoid"}
-	subspecies := Species{
-		initialTraitPoints: 2,
+// secondarySpecies starts the secondary species from its template. Traits the civics grant to every
+// species of the empire are left out when the archetype of the secondary species cannot have them.
+func secondarySpecies(empire Empire, rules []speciesRules, template speciesTemplate) Species {
+	popTypes := template.popTypes
+	if popTypes == nil {
+		popTypes = organicPopTypes(empire)
+	}
+	if template.samePopType {
+		popTypes = []string{empire.mainSpecies.popType}
+	}
+	if template.otherPopType {
+		popTypes = withoutPopType(popTypes, empire.mainSpecies.popType)
+	}
+	granted := []Trait{}
+	for _, name := range template.traits {
+		granted = append(granted, originTraits[name])
+	}
+	popTypes = compatiblePopTypes(popTypes, granted)
+	if len(popTypes) == 0 {
+		// the civics and origin leave no other pop type, such as Calamitous Birth, so any organic pop type will do
+		popTypes = compatiblePopTypes(empire.catalogue().popTypes, granted)
+		if template.otherPopType && len(popTypes) > 1 {
+			popTypes = withoutPopType(popTypes, empire.mainSpecies.popType)
+		}
+	}
+	species := newSpecies(randomPopType(popTypes))
+	species.allowLeftover, species.version = empire.allowLeftover, empire.version
+	species = species.withBudget(template.budget)
+	for _, rule := range rules {
+		species = species.withRules(rule)
+	}
+	for _, trait := range granted {
+		species = withTrait(species, trait.name)
+	}
+	for _, rule := range rules {
+		for _, name := range rule.subTraits {
+			if originTraits[name].isAllowed.test(species) {
+				species = withTrait(species, name)
+			}
+		}
 	}
+	return species
+}
+
+// compatiblePopTypes drops the pop types whose implicit traits clash with the granted traits.
+func compatiblePopTypes(popTypes []string, granted []Trait) []string {
+	res := []string{}
+	for _, popType := range popTypes {
+		if implicitProblem(popType, granted) == "" {
+			res = append(res, popType)
+		}
+	}
+	return res
+}
+
+// speciesRulesOf lists the species rules of the civics, origin and authority, in that order.
+func speciesRulesOf(empire Empire) []speciesRules {
+	res := []speciesRules{}
 	for _, civic := range empire.civics {
-		if civic.name == "Anglers" {
-			species.traits = append(species.traits, originTraits["Aquatic"])
-			subspecies.traits = append(subspecies.traits, originTraits["Aquatic"])
-		}
-		if civic.name == "Idyllic Bloom" {
-			popTypes = []string{"Fungoid", "Plantoid"}
-		}
-		if civic.name == "Terravore" {
-			popTypes = []string{"Lithoid"}
-		}
-	}
-	if empire.authority == "Machine Intelligence" {
-		//generate machine species
-		species.popType = "Machine"
-		species.initialTraitPoints = 1
-	} else if empire.origin.name == "Calamitous Birth" {
-		//generate lithoid species
-		species.popType = "Lithoid"
-		species.traits = append(species.traits, originTraits["Lithoid"])
-		species.initialTraitPoints = 2
-	} else if empire.origin.name == "Ocean Paradise" {
-		//aquatic species, forced aqautic trait
-		species.popType = "Aquatic"
-		species.traits = append(species.traits, originTraits["Aquatic"])
-		species.initialTraitPoints = 2
-	} else {
-		switch empire.origin.name {
-		case "Clone Soldier":
-			species.traits = append(species.traits, originTraits["Clone Soldier"])
-		case "Post-Apocalyptic":
-			species.traits = append(species.traits, originTraits["Survivor"])
-		case "Void Dwellers":
-			species.traits = append(species.traits, originTraits["Void Dweller"])
-		case "Necrophage":
-			species.traits = append(species.traits, originTraits["Necrophage"])
-			generateSubSpecies = true
-		case "Subterrenean":
-			species.traits = append(species.traits, originTraits["Cave Dweller"])
-		case "Syncretic Evolution":
-			generateSubSpecies = true
-			subspecies.traits = append(subspecies.traits, originTraits["Serviles"])
-		case "Driven Assimilator":
-			generateSubSpecies = true
-		}
-		//standard species
-		species.popType = popTypes[r.Intn(len(popTypes))]
-		species.initialTraitPoints = 2
-	}
-	empire.mainSpecies = fillSpecies(species, empire.authority == "Hive Mind", empire.origin.name == "Overtuned")
-	if generateSubSpecies {
-		subspecies.popType = popTypes[r.Intn(len(popTypes))]
-		empire.subSpecies = fillSpecies(subspecies, empire.authority == "Hive Mind", empire.origin.name == "Overtuned")
+		res = append(res, civic.species)
 	}
-	return empire
+	res = append(res, empire.origin.species)
+	for _, auth := range empire.catalogue().authorities {
+		if auth.name == empire.authority {
+			res = append(res, auth.species)
+		}
+	}
+	return res
+}
+
+// organicPopTypes are the pop types the civics and origin leave, the origin overriding the civics.
+func organicPopTypes(empire Empire) []string {
+	res := empire.catalogue().popTypes
+	for _, rule := range speciesRulesOf(empire) {
+		if rule.popTypes != nil && !contains(rule.popTypes, "Machine") {
+			res = rule.popTypes
+		}
+	}
+	return res
+}
+
+// mainPopTypes are the pop types of the main species, where the authority overrides the origin and civics.
+func mainPopTypes(empire Empire) []string {
+	res := empire.catalogue().popTypes
+	for _, rule := range speciesRulesOf(empire) {
+		if rule.popTypes != nil {
+			res = rule.popTypes
+		}
+	}
+	return res
+}
+
+// subSpeciesTemplate returns the last sub species template of the rules, or nil when the empire has no sub species.
+func subSpeciesTemplate(rules []speciesRules) *speciesTemplate {
+	var res *speciesTemplate
+	for _, rule := range rules {
+		if rule.subSpecies != nil {
+			res = rule.subSpecies
+		}
+	}
+	return res
+}
+
+// withTrait adds a granted trait unless the species already has it, as several rules may grant the same trait.
+func withTrait(species Species, name string) Species {
+	for _, trait := range species.traits {
+		if trait.name == name {
+			return species
+		}
+	}
+	species.traits = append(species.traits, originTraits[name])
+	return species
+}
+
+func withoutPopType(popTypes []string, popType string) []string {
+	res := []string{}
+	for _, other := range popTypes {
+		if other != popType {
+			res = append(res, other)
+		}
+	}
+	return res
+}
+
+// subSpeciesName is what the secondary species is to the empire, such as Serviles or Bio-Trophies.
+func subSpeciesName(empire Empire) string {
+	if template := subSpeciesTemplate(speciesRulesOf(empire)); template != nil && template.name != "" {
+		return template.name
+	}
+	return "Sub Species"
+}
+
+// traitPool lists the traits the species may pick from, before their rules are checked.
+func traitPool(s Species) []Trait {
+	c := s.catalogue()
+	if s.overtuned {
+		return append(append([]Trait{}, c.traits...), c.overtuned...)
+	}
+	return c.traits
+}
+
+// splitOvertuned separates the overtuned traits, which the card lists on their own.
+func splitOvertuned(traits []Trait) (regular []Trait, overtuned []Trait) {
+	for _, trait := range traits {
+		if isOvertuned(trait.name) {
+			overtuned = append(overtuned, trait)
+		} else {
+			regular = append(regular, trait)
+		}
+	}
+	return regular, overtuned
 }
 
-func fillSpecies(s Species, gestalt bool, overtuned bool) Species {
-	for {
-		result, ok := singleSpeciesTry(s, gestalt, overtuned)
+func isOvertuned(name string) bool {
+	for _, trait := range overtunedTraits {
+		if trait.name == name {
+			return true
+		}
+	}
+	return false
+}
+
+// lifespanMalus adds up what the overtuned traits of the species take off the lifespan of its leaders.
+func lifespanMalus(s Species) int {
+	res := 0
+	for _, trait := range s.traits {
+		if isOvertuned(trait.name) {
+			res += trait.lifespan
+		}
+	}
+	return res
+}
+
+func randomPopType(popTypes []string) string {
+	return popTypes[r.Intn(len(popTypes))]
+}
+
+// maxSpeciesTries bounds the rerolls of a species like maxEmpireTries those of an empire, so a budget that
+// no traits of the pool fit ends in an error instead of a hang.
+const maxSpeciesTries = 1000
+
+// fillSpecies keeps drawing traits until the points add up. Only the accepted try ends up in the trace.
+func fillSpecies(s Species, gestalt bool, t *trace, label string) (Species, error) {
+	for tries := 1; tries <= maxSpeciesTries; tries++ {
+		var try *trace
+		if t != nil {
+			try = &trace{}
+		}
+		result, ok := singleSpeciesTry(s, gestalt, try, label)
 		if ok {
-			return result
+			if t != nil {
+				t.steps = append(t.steps, try.steps...)
+				step := t.begin("fillSpecies", label)
+				step.Drawn = fmt.Sprintf("accepted after %d tries", tries)
+			}
+			return result, nil
 		}
 	}
+	return s, fmt.Errorf("no traits of the %s fit its budget after %d tries", label, maxSpeciesTries)
 }
 
-func singleSpeciesTry(s Species, gestalt bool, overtuned bool) (Species, bool) {
-	traitCountOptions := []int{1, 2, 3, 3, 4, 4, 5, 5, 5}
+func singleSpeciesTry(s Species, gestalt bool, t *trace, label string) (Species, bool) {
+	base := s
+	traitCountOptions := traitCounts(s)
+	if len(traitCountOptions) == 0 {
+		return s, budgetProblem(base, s) == ""
+	}
 	traitsToGenerate := traitCountOptions[r.Intn(len(traitCountOptions))]
 	for i := 0; i < traitsToGenerate; i++ {
-		traits := availableTraits(s, gestalt, overtuned)
+		step := t.begin("availableTraits", fmt.Sprintf("%s, trait %d of %d", label, i+1, traitsToGenerate))
+		traits := availableTraits(s, gestalt, step)
+		if len(traits) == 0 {
+			return Species{}, false
+		}
 		s.traits = append(s.traits, traits[r.Intn(len(traits))])
+		if step != nil {
+			for _, trait := range traits {
+				step.Candidates = append(step.Candidates, trait.name)
+			}
+			step.Drawn = s.traits[len(s.traits)-1].name
+		}
 	}
-	res := s.initialTraitPoints
-	for _, trait := range s.traits {
-		res -= trait.cost
-	}
-	if res == 0 {
+	if budgetProblem(base, s) == "" {
 		return s, true
 	}
 	return Species{}, false
 }
 
-func availableTraits(s Species, gestalt bool, overtuned bool) []Trait {
+// traitCounts lists how many traits a species may draw, a count appearing as often as it is likely.
+func traitCounts(s Species) []int {
+	res := []int{}
+	for _, count := range []int{1, 2, 3, 3, 4, 4, 5, 5, 5} {
+		if count <= s.maxTraits {
+			res = append(res, count)
+		}
+	}
+	return res
+}
+
+func availableTraits(s Species, gestalt bool, step *traceStep) []Trait {
 	result := []Trait{}
 outer:
-	for _, trait := range allTraits {
-		if !trait.isAllowed(s) || (trait.nonGestalt && gestalt) {
+	for _, trait := range traitPool(s) {
+		if !trait.isAllowed.test(s) {
+			if step != nil {
+				step.exclude(trait.name, trait.isAllowed.reason(s))
+			}
+			continue
+		}
+		if trait.nonGestalt && gestalt {
+			step.exclude(trait.name, "not available to gestalt empires")
 			continue
 		}
 		for _, sTrait := range s.traits {
 			if trait.name == sTrait.name {
+				step.exclude(trait.name, "already chosen")
 				continue outer
 			}
 		}
 		result = append(result, trait)
 	}
-	if overtuned {
-	overtunedLoop:
-		for _, trait := range overtunedTraits {
-			if !trait.isAllowed(s) || (trait.nonGestalt && gestalt) {
-				continue
-			}
-			for _, sTrait := range s.traits {
-				if trait.name == sTrait.name {
-					continue overtunedLoop
-				}
-			}
-			result = append(result, trait)
-		}
-	}
 	return result
 }
 
 type Empire struct {
-	authority   string
-	civics      []Civic
-	ethics      []Ethic
-	origin      Origin
-	homeplanet  string
-	mainSpecies Species
-	subSpecies  Species
+	player        string
+	authority     string
+	civics        []Civic
+	ethics        []Ethic
+	origin        Origin
+	homeplanet    string
+	mainSpecies   Species
+	subSpecies    Species
+	mainBase      Species // species before random traits were added
+	subBase       Species
+	odds          odds
+	trace         *trace
+	allowLeftover bool     // see Species.allowLeftover
+	fanaticChance float64  // chance that a drawn ethic becomes fanatic
+	predictCivic  bool     // draw the civic of the first locked slot
+	predicted     Civic    // the predicted civic, empty unless predictCivic is set
+	order         []string // names of generationSteps, defaultOrder when nil
+	shuffleOrder  bool     // shuffle the generation steps instead
+	version       string   // name of the catalogue version, see gameVersion
+	nameSeed      int64    // seeds generateNames, so names survive a share code
 }
 
-type Predicate func(empire Empire) bool
+var allPopTypes = []string{"Aquatic", "Mammalian", "Reptilian", "Avian", "Arthropoid", "Molluscoid", "Fungoid", "Plantoid", "Lithoid", "Necroid", "Toxoid"}
+
+// Predicate is a rule an empire has to satisfy. It is kept as data rather than a closure,
+// so the generator can explain which rule filtered an option out.
+type Predicate struct {
+	kind  string
+	names []string
+	parts []Predicate
+}
 
 func (e Civic) String() string {
 	return e.name
@@ -353,62 +947,121 @@ type Civic struct {
 	name      string
 	genocidal bool
 	isAllowed Predicate // should only check for other civics and authority
+	species   speciesRules
 }
 
 type Ethic struct {
 	name      string
+	regular   string // the regular form of a fanatic ethic, empty for regular ethics
+	axis      string
+	points    int
 	isAllowed Predicate // checks if valid for civics, authority and other ethics
 }
 
+// maxEthicPoints is what the ethics of every empire add up to.
+const maxEthicPoints = 3
+
+// defaultFanaticChance is the chance that a drawn ethic becomes fanatic, when its points allow it.
+const defaultFanaticChance = 0.5
+
+// regularName is the name of the regular form of the ethic.
+func (e Ethic) regularName() string {
+	if e.regular != "" {
+		return e.regular
+	}
+	return e.name
+}
+
 type Origin struct {
 	name      string
 	isAllowed Predicate // checks if valid for civics, authority and ethics
+	species   speciesRules
 }
 
 type Authority struct {
 	name      string
 	isAllowed Predicate
+	species   speciesRules
+}
+
+// speciesRules is what a civic, origin or authority does to the species of the empire.
+// Trait names refer to originTraits, as these traits are only granted and never drawn.
+type speciesRules struct {
+	traits     []string // forced on the main species
+	subTraits  []string // forced on the sub species, if there is one
+	popTypes   []string // restricts the pop type, later rules override earlier ones
+	budget     budget   // added to the budget of every species of the empire
+	overtuned  bool     // every species of the empire may pick overtunedTraits
+	subSpecies *speciesTemplate
+}
+
+// speciesTemplate describes the secondary species an origin or civic adds. When both bring one,
+// the origin wins, as an empire has a single secondary species.
+type speciesTemplate struct {
+	name         string   // what the secondary species is to the empire, shown on the card
+	popTypes     []string // nil allows every pop type the civics and origin allow
+	samePopType  bool     // shares the pop type of the main species
+	otherPopType bool     // never shares the pop type of the main species
+	traits       []string
+	budget       budget
 }
 
 type Species struct {
 	popType            string
 	initialTraitPoints int
+	maxTraits          int
+	maxNegative        int
+	allowLeftover      bool // accept traits that leave trait points unspent
+	overtuned          bool // may pick overtunedTraits
 	traits             []Trait
+	preferredClass     string
+	version            string // name of the catalogue version, the one of the empire
 }
 
-type speciesPredicate func(s Species) bool
+type speciesPredicate struct {
+	kind  string
+	names []string
+	parts []speciesPredicate
+}
 
 type Trait struct {
 	cost       int
 	name       string
 	nonGestalt bool
+	lifespan   int // years added to the lifespan of leaders of the species, negative for overtuned traits
 	isAllowed  speciesPredicate
 }
 
-func always(empire Empire) bool {
-	return true
-}
+var always = Predicate{kind: "always"}
 
 func normalAuth() Predicate {
 	return auth("Democratic", "Oligarchy", "Dictatorial", "Imperial")
 }
 
-func onlyGestalt(empire Empire) bool {
-	return len(empire.ethics) == 0
-}
+var onlyGestalt = Predicate{kind: "onlyGestalt"}
 
+// allEthics lists the regular and fanatic form of every ethic. Ethics on the same axis exclude each other,
+// whichever form they take, and the points of all ethics of an empire add up to maxEthicPoints.
 var allEthics = []Ethic{
-	{name: "Authoritarian", isAllowed: excludeEthic("Egalitarian", "Fanatic Egalitarian", "Gestalt Consciousness")},
-	{name: "Spiritualist", isAllowed: excludeEthic("Materialist", "Fanatic Materialist", "Gestalt Consciousness")},
-	{name: "Militarist", isAllowed: excludeEthic("Pacifist", "Fanatic Pacifist", "Gestalt Consciousness")},
-	{name: "Xenophobe", isAllowed: excludeEthic("Xenophile", "Fanatic Xenophile", "Gestalt Consciousness")},
-	{name: "Egalitarian", isAllowed: excludeEthic("Authoritarian", "Fanatic Authoritarian", "Gestalt Consciousness")},
-	{name: "Materialist", isAllowed: excludeEthic("Spiritualist", "Fanatic Spiritualist", "Gestalt Consciousness")},
-	{name: "Pacifist", isAllowed: excludeEthic("Militarist", "Fanatic Militarist", "Gestalt Consciousness")},
-	{name: "Xenophile", isAllowed: excludeEthic("Xenophobe", "Fanatic Xenophobe", "Gestalt Consciousness")},
-	// {name: "Gestalt Consciousness", isAllowed: onlyGestalt},
-	{name: "Gestalt Consciousness", isAllowed: onlyGestalt},
-	{name: "Gestalt Consciousness", isAllowed: onlyGestalt},
+	{name: "Authoritarian", axis: "authority", points: 1, isAllowed: excludeEthic("Gestalt Consciousness")},
+	{name: "Fanatic Authoritarian", regular: "Authoritarian", axis: "authority", points: 2, isAllowed: excludeEthic("Gestalt Consciousness")},
+	{name: "Spiritualist", axis: "spirit", points: 1, isAllowed: excludeEthic("Gestalt Consciousness")},
+	{name: "Fanatic Spiritualist", regular: "Spiritualist", axis: "spirit", points: 2, isAllowed: excludeEthic("Gestalt Consciousness")},
+	{name: "Militarist", axis: "violence", points: 1, isAllowed: excludeEthic("Gestalt Consciousness")},
+	{name: "Fanatic Militarist", regular: "Militarist", axis: "violence", points: 2, isAllowed: excludeEthic("Gestalt Consciousness")},
+	{name: "Xenophobe", axis: "xenos", points: 1, isAllowed: excludeEthic("Gestalt Consciousness")},
+	{name: "Fanatic Xenophobe", regular: "Xenophobe", axis: "xenos", points: 2, isAllowed: excludeEthic("Gestalt Consciousness")},
+	{name: "Egalitarian", axis: "authority", points: 1, isAllowed: excludeEthic("Gestalt Consciousness")},
+	{name: "Fanatic Egalitarian", regular: "Egalitarian", axis: "authority", points: 2, isAllowed: excludeEthic("Gestalt Consciousness")},
+	{name: "Materialist", axis: "spirit", points: 1, isAllowed: excludeEthic("Gestalt Consciousness")},
+	{name: "Fanatic Materialist", regular: "Materialist", axis: "spirit", points: 2, isAllowed: excludeEthic("Gestalt Consciousness")},
+	{name: "Pacifist", axis: "violence", points: 1, isAllowed: excludeEthic("Gestalt Consciousness")},
+	{name: "Fanatic Pacifist", regular: "Pacifist", axis: "violence", points: 2, isAllowed: excludeEthic("Gestalt Consciousness")},
+	{name: "Xenophile", axis: "xenos", points: 1, isAllowed: excludeEthic("Gestalt Consciousness")},
+	{name: "Fanatic Xenophile", regular: "Xenophile", axis: "xenos", points: 2, isAllowed: excludeEthic("Gestalt Consciousness")},
+	// Gestalt Consciousness is listed twice, so one empire in five is a gestalt
+	{name: "Gestalt Consciousness", axis: "gestalt", points: 3, isAllowed: onlyGestalt},
+	{name: "Gestalt Consciousness", axis: "gestalt", points: 3, isAllowed: onlyGestalt},
 }
 
 var allAuthorities = []Authority{
@@ -418,14 +1071,14 @@ var allAuthorities = []Authority{
 	{name: "Imperial", isAllowed: excludeEthic("Egalitarian", "Fanatic Egalitarian", "Gestalt Consciousness")},
 	{name: "Corporate", isAllowed: excludeEthic("Fanatic Egalitarian", "Fanatic Authoritarian", "Gestalt Consciousness")},
 	{name: "Hive Mind", isAllowed: includeEthic("Gestalt Consciousness")},
-	{name: "Machine Intelligence", isAllowed: includeEthic("Gestalt Consciousness")},
+	{name: "Machine Intelligence", isAllowed: includeEthic("Gestalt Consciousness"), species: speciesRules{popTypes: []string{"Machine"}}},
 }
 
 var allCivics = []Civic{
 	{name: "Constructobot", isAllowed: auth("Machine Intelligence")},
 	{name: "Delegated Functions", isAllowed: auth("Machine Intelligence")},
 	{name: "Determined Exterminator", isAllowed: and(auth("Machine Intelligence"), excludeCivic("Driven Assimilator", "Rogue Servitor")), genocidal: true},
-	{name: "Driven Assimilator", isAllowed: and(auth("Machine Intelligence"), excludeCivic("Determined Exterminator", "Rogue Servitor")), genocidal: true},
+	{name: "Driven Assimilator", isAllowed: and(auth("Machine Intelligence"), excludeCivic("Determined Exterminator", "Rogue Servitor")), genocidal: true, species: speciesRules{subSpecies: &speciesTemplate{name: "Cyborgs", traits: []string{"Cyborg"}, budget: budget{points: -1}}}},
 	{name: "Factory Overclocking", isAllowed: auth("Machine Intelligence")},
 	{name: "Introspective", isAllowed: auth("Machine Intelligence")},
 	{name: "Maintenance Protocols", isAllowed: auth("Machine Intelligence")},
@@ -434,17 +1087,17 @@ var allCivics = []Civic{
 	{name: "Organic Reprocessing", isAllowed: auth("Machine Intelligence")},
 	{name: "Rapid Replicator", isAllowed: auth("Machine Intelligence")},
 	{name: "Rockbreakers", isAllowed: auth("Machine Intelligence")},
-	{name: "Rogue Servitor", isAllowed: and(auth("Machine Intelligence"), excludeCivic("Determined Exterminator", "Driven Assimilator"))},
+	{name: "Rogue Servitor", isAllowed: and(auth("Machine Intelligence"), excludeCivic("Determined Exterminator", "Driven Assimilator")), species: speciesRules{subSpecies: &speciesTemplate{name: "Bio-Trophies"}}},
 	{name: "Static Research Analysis", isAllowed: auth("Machine Intelligence")},
 	{name: "Unitary Cohesion", isAllowed: auth("Machine Intelligence")},
 	{name: "Warbots", isAllowed: auth("Machine Intelligence")},
 	{name: "Zero-Waste Protocols", isAllowed: auth("Machine Intelligence")},
 	{name: "Ascetic", isAllowed: auth("Hive Mind")},
 	{name: "Devouring Swarm", isAllowed: and(auth("Hive Mind"), excludeCivic("Terravore", "Empath")), genocidal: true},
-	{name: "Terravore", isAllowed: and(auth("Hive Mind"), excludeCivic("Devouring Swarm", "Empath", "Idyllic Bloom")), genocidal: true},
+	{name: "Terravore", isAllowed: and(auth("Hive Mind"), excludeCivic("Devouring Swarm", "Empath", "Idyllic Bloom")), genocidal: true, species: speciesRules{popTypes: []string{"Lithoid"}}},
 	{name: "Divided Attention", isAllowed: auth("Hive Mind")},
 	{name: "Empath", isAllowed: and(auth("Hive Mind"), excludeCivic("Terravore", "Devouring Swarm"))},
-	{name: "Idyllic Bloom", isAllowed: and(auth("Hive Mind"), excludeCivic("Terravore"))},
+	{name: "Idyllic Bloom", isAllowed: and(auth("Hive Mind"), excludeCivic("Terravore")), species: speciesRules{popTypes: []string{"Fungoid", "Plantoid"}}},
 	{name: "Memorialist", isAllowed: auth("Hive Mind")},
 	{name: "Natural Neural Network", isAllowed: auth("Hive Mind")},
 	{name: "One Mind", isAllowed: auth("Hive Mind")},
@@ -471,7 +1124,7 @@ var allCivics = []Civic{
 	{name: "Indentured Assets", isAllowed: and(auth("Corporate"), excludeCivic("Corporate Hedonism"), includeEthic("Authoritarian", "Fanatic Authoritarian"))},
 	{name: "Naval Contractors", isAllowed: and(auth("Corporate"), includeEthic("Militarist", "Fanatic Militarist"))},
 	{name: "Private Military Companies", isAllowed: and(auth("Corporate"), includeEthic("Militarist", "Fanatic Militarist"))},
-	{name: "Anglers", isAllowed: and(normalAuth(), excludeCivic("Agrarian Idyll"))},
+	{name: "Anglers", isAllowed: and(normalAuth(), excludeCivic("Agrarian Idyll")), species: speciesRules{traits: []string{"Aquatic"}, subTraits: []string{"Aquatic"}}},
 	{name: "Byzantine Bureaucracy", isAllowed: and(normalAuth(), excludeEthic("Spiritualist", "Fanatic Spiritualist"))},
 	{name: "Corvee System", isAllowed: and(normalAuth(), excludeCivic("Free Haven"), excludeEthic("Egalitarian", "Fanatic Egalitarian"))},
 	{name: "Cutthroat Politics", isAllowed: normalAuth()},
@@ -495,7 +1148,7 @@ var allCivics = []Civic{
 	{name: "Exalted Priesthood", isAllowed: and(auth("Oligarchy", "Dictatorial"), excludeCivic("Aristocratic Elite", "Merchant Guilds", "Technocracy"), includeEthic("Spiritualist", "Fanatic Spiritualist"))},
 	{name: "Feudal Society", isAllowed: auth("Imperial")},
 	{name: "Free Haven", isAllowed: and(normalAuth(), excludeCivic("Corvee System"), includeEthic("Xenophile", "Fanatic Xenophile"))},
-	{name: "Idyllic Bloom", isAllowed: and(normalAuth(), excludeCivic("Relentless Industrialists"))},
+	{name: "Idyllic Bloom", isAllowed: and(normalAuth(), excludeCivic("Relentless Industrialists")), species: speciesRules{popTypes: []string{"Fungoid", "Plantoid"}}},
 	{name: "Imperial Cult", isAllowed: and(auth("Imperial"), includeEthic("Spiritualist", "Fanatic Spiritualist"), includeEthic("Authoritarian", "Fanatic Authoritarian"))},
 	{name: "Inward Perfection", isAllowed: and(normalAuth(), excludeCivic("Pompous Purists"), includeEthic("Pacifist", "Fanatic Pacifist"), includeEthic("Xenophobe", "Fanatic Xenophobe"))},
 	{name: "Meritocracy", isAllowed: auth("Democratic", "Oligarchy")},
@@ -510,7 +1163,7 @@ var allCivics = []Civic{
 	{name: "Relentless Industrialists", isAllowed: and(auth("Democratic", "Oligarchy", "Dictatorial", "Imperial", "Corporate"), includeEthic("Materialist", "Fanatic Materialist"), excludeCivic("Agrarian Idyll", "Environmentalist", "Idyllic Bloom", "Memorialists"))},
 	{name: "Scavengers", isAllowed: and(auth("Democratic", "Oligarchy", "Dictatorial", "Imperial", "Corporate"))},
 	{name: "Ascensionists", isAllowed: and(auth("Democratic", "Oligarchy", "Dictatorial", "Imperial", "Corporate"), includeEthic("Spiritualist", "Fanatic Spiritualist"))},
-	{name: "Permutation Pools", isAllowed: auth("Hive Mind")},
+	{name: "Permutation Pools", isAllowed: auth("Hive Mind"), species: speciesRules{budget: budget{points: 1}}},
 	{name: "Cordiceptic Drones", isAllowed: auth("Hive Mind")},
 	{name: "Elevational Contemplations", isAllowed: auth("Hive Mind")},
 	{name: "Hyper Lubrication Basin", isAllowed: auth("Machine Intelligence")},
@@ -525,258 +1178,447 @@ var allCivics = []Civic{
 
 var allOrigins = []Origin{
 	{name: "Prosperous Unification", isAllowed: always},
-	{name: "Mechanist", isAllowed: and(includeEthic("Materialist", "Fanatic Materialist"), excludeCivic("Permanent Employment"))},
-	{name: "Syncretic Evolution", isAllowed: and(excludeEthic("Gestalt Consciousness"), excludeCivic("Fanatic Purifiers"))},
+	{name: "Mechanist", isAllowed: and(includeEthic("Materialist", "Fanatic Materialist"), excludeCivic("Permanent Employment")), species: speciesRules{subSpecies: &speciesTemplate{name: "Robots", popTypes: []string{"Robot"}}}},
+	{name: "Syncretic Evolution", isAllowed: and(excludeEthic("Gestalt Consciousness"), excludeCivic("Fanatic Purifiers")), species: speciesRules{subSpecies: &speciesTemplate{name: "Serviles", traits: []string{"Serviles"}, otherPopType: true}}},
 	{name: "Life-Seeded", isAllowed: and(notAuth("Machine Intelligence"), excludeCivic("Anglers", "Mutagenic Spas", "Relentless Industrialists", "Permutation Pools"))},
-	{name: "Post-Apocalyptic", isAllowed: and(notAuth("Machine Intelligence"), excludeCivic("Agrarian Idyll", "Anglers"))},
+	{name: "Post-Apocalyptic", isAllowed: and(notAuth("Machine Intelligence"), excludeCivic("Agrarian Idyll", "Anglers")), species: speciesRules{traits: []string{"Survivor"}}},
 	{name: "Remnants", isAllowed: excludeCivic("Agrarian Idyll")},
 	{name: "Shattered Ring", isAllowed: excludeCivic("Agrarian Idyll", "Anglers")},
-	{name: "Void Dwellers", isAllowed: and(excludeEthic("Gestalt Consciousness"), excludeCivic("Idyllic Bloom", "Agrarian Idyll", "Anglers"))},
+	{name: "Void Dwellers", isAllowed: and(excludeEthic("Gestalt Consciousness"), excludeCivic("Idyllic Bloom", "Agrarian Idyll", "Anglers")), species: speciesRules{traits: []string{"Void Dweller"}}},
 	{name: "Scion", isAllowed: and(excludeEthic("Gestalt Consciousness", "Fanatic Xenophobe"), excludeCivic("Pompous Purists"))},
 	{name: "Galactic Doorstep", isAllowed: always},
 	{name: "Tree of Life", isAllowed: and(auth("Hive Mind"), excludeCivic("Devouring Swarm", "Terravore"))},
 	{name: "On the Shoulders of Giants", isAllowed: excludeEthic("Gestalt Consciousness")},
-	{name: "Calamitous Birth", isAllowed: and(excludeCivic("Catalytic Processing", "Organic Reprocessing", "Catalytic Recyclers", "Devouring Swarm", "Idyllic Bloom"), notAuth("Machine Intelligence"))},
+	{name: "Calamitous Birth", isAllowed: and(excludeCivic("Catalytic Processing", "Organic Reprocessing", "Catalytic Recyclers", "Devouring Swarm", "Idyllic Bloom"), notAuth("Machine Intelligence")), species: speciesRules{traits: []string{"Lithoid"}, popTypes: []string{"Lithoid"}}},
 	{name: "Resource Consolidation", isAllowed: and(auth("Machine Intelligence"), excludeCivic("Rogue Servitor", "Organic Reprocessing"))},
-	{name: "Common Ground", isAllowed: and(excludeEthic("Gestalt Consciousness", "Xenophobe", "Fanatic Xenophobe"), excludeCivic("Barbaric Despoilers", "Fanatic Purifiers", "Inward Perfection"))},
+	{name: "Common Ground", isAllowed: and(excludeEthic("Gestalt Consciousness", "Xenophobe", "Fanatic Xenophobe"), excludeCivic("Barbaric Despoilers", "Fanatic Purifiers", "Inward Perfection")), species: speciesRules{subSpecies: &speciesTemplate{name: "Federation partners", otherPopType: true}}},
 	{name: "Hegemon", isAllowed: and(excludeEthic("Gestalt Consciousness", "Xenophobe", "Fanatic Xenophobe", "Egalitarian", "Fanatic Egalitarian"), excludeCivic("Fanatic Purifiers", "Inward Perfection"))},
 	{name: "Doomsday", isAllowed: always},
 	{name: "Lost Colony", isAllowed: excludeEthic("Gestalt Consciousness")},
-	{name: "Necrophage", isAllowed: and(excludeEthic("Xenophile", "Fanatic Xenophile", "Fanatic Egalitarian"), notAuth("Machine Intelligence"), excludeCivic("Death Cult", "Corporate Death Cult", "Empath", "Permanent Employment"))},
-	{name: "Clone Army", isAllowed: and(excludeEthic("Gestalt Consciousness"), excludeCivic("Permanent Employment"))},
+	{name: "Necrophage", isAllowed: and(excludeEthic("Xenophile", "Fanatic Xenophile", "Fanatic Egalitarian"), notAuth("Machine Intelligence"), excludeCivic("Death Cult", "Corporate Death Cult", "Empath", "Permanent Employment")), species: speciesRules{traits: []string{"Necrophage"}, subSpecies: &speciesTemplate{name: "Prepatent species", otherPopType: true}}},
+	{name: "Clone Army", isAllowed: and(excludeEthic("Gestalt Consciousness"), excludeCivic("Permanent Employment")), species: speciesRules{traits: []string{"Clone Soldier"}}},
 	{name: "Here Be Dragons", isAllowed: excludeCivic("Fanatic Purifiers", "Devouring Swarm", "Terravore", "Determined Exterminator")},
-	{name: "Ocean Paradise", isAllowed: notAuth("Machine Intelligence")},
-	{name: "Progenitor Hive", isAllowed: auth("Hive Mind")},
-	{name: "Subterrenean", isAllowed: and(notAuth("Machine Intelligence"), excludeCivic("Anglers"))},
+	{name: "Ocean Paradise", isAllowed: notAuth("Machine Intelligence"), species: speciesRules{traits: []string{"Aquatic"}, popTypes: []string{"Aquatic"}}},
+	{name: "Progenitor Hive", isAllowed: auth("Hive Mind"), species: speciesRules{subSpecies: &speciesTemplate{name: "Offspring drones", samePopType: true, budget: budget{points: 1}}}},
+	{name: "Subterrenean", isAllowed: and(notAuth("Machine Intelligence"), excludeCivic("Anglers")), species: speciesRules{traits: []string{"Cave Dweller"}}},
 	{name: "Slingshot to the Stars", isAllowed: always},
 	{name: "Teachers of the Shroud", isAllowed: and(includeEthic("Spiritualist", "Fanatic Spiritualist"), excludeCivic("Fanatic Purifiers"))},
 	{name: "Imperial Fiefdom", isAllowed: excludeCivic("Inward Perfection", "Fanatic Purifiers", "Devouring Swarm", "Terravore", "Driven Assimilator", "Determined Exterminator")},
 	{name: "Knights of the Toxic God", isAllowed: and(excludeCivic("Fanatic Purifiers"), excludeEthic("Gestalt Consciousness"))},
-	{name: "Overtuned", isAllowed: notAuth("Machine Intelligence")},
+	{name: "Overtuned", isAllowed: notAuth("Machine Intelligence"), species: speciesRules{budget: budget{points: 2, maxTraits: 1}, overtuned: true}},
 }
 
 var allTraits = []Trait{
-	{name: "Adaptive", cost: 2, isAllowed: andS(excludeType("Machine"), excludeTrait("Extremely Adaptive", "Nonadaptive", "Lithoid"))},
-	{name: "Extremely Adaptive", cost: 4, isAllowed: andS(excludeType("Machine"), excludeTrait("Adaptive", "Nonadaptive", "Lithoid"))},
-	{name: "Agrarian", cost: 2, isAllowed: andS(excludeType("Machine"), excludeTrait("Lithoid"))},
-	{name: "Aquatic", cost: 2, isAllowed: andS(excludeType("Machine"), excludeTrait("Cave Dweller"))},
-	{name: "Charismatic", cost: 2, isAllowed: andS(excludeType("Machine"), excludeTrait("Repugnant"))},
-	{name: "Communal", cost: 1, isAllowed: andS(excludeType("Machine"), excludeTrait("Solitary"))},
-	{name: "Conformists", cost: 2, isAllowed: andS(excludeType("Machine"), excludeTrait("Deviants")), nonGestalt: true},
-	{name: "Conservationist", cost: 1, isAllowed: andS(excludeType("Machine"), excludeTrait("Wasteful"))},
-	{name: "Docile", cost: 2, isAllowed: andS(excludeType("Machine"), excludeTrait("Unruly"))},
-	{name: "Enduring", cost: 1, isAllowed: andS(excludeType("Machine"), excludeTrait("Fleeting", "Venerable"))},
-	{name: "Venerable", cost: 4, isAllowed: andS(excludeType("Machine"), excludeTrait("Fleeting", "Enduring"))},
-	{name: "Industrious", cost: 2, isAllowed: andS(excludeType("Machine"))},
-	{name: "Ingenious", cost: 2, isAllowed: andS(excludeType("Machine"))},
-	{name: "Intelligent", cost: 2, isAllowed: andS(excludeType("Machine"), excludeTrait("Serviles"))},
-	{name: "Natural Engineers", cost: 1, isAllowed: andS(excludeType("Machine"), excludeTrait("Natural Physicists", "Natural Sociologists", "Serviles"))},
-	{name: "Natural Physicists", cost: 1, isAllowed: andS(excludeType("Machine"), excludeTrait("Natural Engineers", "Natural Sociologists", "Serviles"))},
-	{name: "Natural Sociologists", cost: 1, isAllowed: andS(excludeType("Machine"), excludeTrait("Natural Engineers", "Natural Physicists", "Serviles"))},
-	{name: "Nomadic", cost: 1, isAllowed: andS(excludeType("Machine"), excludeTrait("Sedentary"))},
-	{name: "Quick Learners", cost: 1, isAllowed: andS(excludeType("Machine"), excludeTrait("Slow Learners"))},
-	{name: "Rapid Breeders", cost: 2, isAllowed: andS(excludeType("Machine"), excludeTrait("Slow Breeders", "Clone Soldier", "Lithoid"))},
-	{name: "Resilient", cost: 1, isAllowed: andS(excludeType("Machine"))},
-	{name: "Strong", cost: 1, isAllowed: andS(excludeType("Machine"), excludeTrait("Very Strong", "Weak"))},
-	{name: "Very Strong", cost: 3, isAllowed: andS(excludeType("Machine"), excludeTrait("Strong", "Weak"))},
-	{name: "Talented", cost: 1, isAllowed: andS(excludeType("Machine"))},
-	{name: "Thrifty", cost: 2, isAllowed: andS(excludeType("Machine")), nonGestalt: true},
-	{name: "Traditional", cost: 1, isAllowed: andS(excludeType("Machine"), excludeTrait("Quarrelsome"))},
-	{name: "Nonadaptive", cost: -2, isAllowed: andS(excludeType("Machine"), excludeTrait("Adaptive", "Extremely Adaptive", "Lithoid"))},
-	{name: "Repugnant", cost: -2, isAllowed: andS(excludeType("Machine"), excludeTrait("Charismatic"))},
-	{name: "Solitary", cost: -1, isAllowed: andS(excludeType("Machine"), excludeTrait("Communal"))},
-	{name: "Deviants", cost: -1, isAllowed: andS(excludeType("Machine"), excludeTrait("Conformists")), nonGestalt: true},
-	{name: "Wasteful", cost: -1, isAllowed: andS(excludeType("Machine"), excludeTrait("Conservationist"))},
-	{name: "Unruly", cost: -2, isAllowed: andS(excludeType("Machine"), excludeTrait("Docile"))},
-	{name: "Fleeting", cost: -1, isAllowed: andS(excludeType("Machine"), excludeTrait("Enduring", "Venerable"))},
-	{name: "Sedentary", cost: -1, isAllowed: andS(excludeType("Machine"), excludeTrait("Nomadic"))},
-	{name: "Slow Learners", cost: -1, isAllowed: andS(excludeType("Machine"), excludeTrait("Quick Learners"))},
-	{name: "Slow Breeders", cost: -2, isAllowed: andS(excludeType("Machine"), excludeTrait("Rapid Breeders", "Lithoid", "Clone Soldier"))},
-	{name: "Weak", cost: -1, isAllowed: andS(excludeType("Machine"), excludeTrait("Strong", "Very Strong"))},
-	{name: "Quarrelsome", cost: -1, isAllowed: andS(excludeType("Machine"), excludeTrait("Traditional"))},
-	{name: "Decadent", cost: -1, isAllowed: andS(excludeType("Machine")), nonGestalt: true},
-	{name: "Phototropic", cost: 1, isAllowed: andS(includeType("Plantoid", "Fungoid"), excludeTrait("Radiotropic", "Cave Dweller"))},
-	{name: "Radiotropic", cost: 2, isAllowed: andS(includeType("Plantoid", "Fungoid"), excludeTrait("Phototropic"))},
-	{name: "Budding", cost: 2, isAllowed: andS(includeType("Plantoid", "Fungoid"), excludeTrait("Slow Breeders", "Rapid Breeders", "Clone Soldier", "Necrophage"))},
-	{name: "Gaseous Byproducts", cost: 2, isAllowed: andS(includeType("Lithoid"), excludeTrait("Scintillating Skin", "Volatile Excretions"))},
-	{name: "Scintillating Skin", cost: 2, isAllowed: andS(includeType("Lithoid"), excludeTrait("Gaseous Byproducts", "Volatile Excretions"))},
-	{name: "Volatile Excretions", cost: 2, isAllowed: andS(includeType("Lithoid"), excludeTrait("Gaseous Byproducts", "Scintillating Skin"))},
-	{name: "Crystallization", cost: 2, isAllowed: andS(includeType("Lithoid"), excludeTrait("Slow Breeders", "Rapid Breeders", "Incubators", "Clone Soldier", "Necrophage"))},
-	{name: "Double Jointed", cost: 1, isAllowed: andS(includeType("Machine"), excludeTrait("Bulky"))},
-	{name: "Durable", cost: 1, isAllowed: andS(includeType("Machine"), excludeTrait("High Maintenance"))},
-	{name: "Efficient Processors", cost: 3, isAllowed: andS(includeType("Machine"))},
-	{name: "Emotion Emulators", cost: 1, isAllowed: andS(includeType("Machine"), excludeTrait("Uncanny"))},
-	{name: "Enhanced Memory", cost: 2, isAllowed: andS(includeType("Machine"))},
-	{name: "Logic Engines", cost: 2, isAllowed: andS(includeType("Machine"))},
-	{name: "Mass-Produced", cost: 1, isAllowed: andS(includeType("Machine"), excludeTrait("Custom-Made"))},
-	{name: "Power Drills", cost: 2, isAllowed: andS(includeType("Machine"))},
-	{name: "Recycled", cost: 2, isAllowed: andS(includeType("Machine"), excludeTrait("Luxurious"))},
-	{name: "Streamlined Protocols", cost: 2, isAllowed: andS(includeType("Machine"), excludeTrait("High Bandwidth"))},
-	{name: "Superconducive", cost: 2, isAllowed: andS(includeType("Machine"))},
-	{name: "Bulky", cost: -1, isAllowed: andS(includeType("Machine"), excludeTrait("Double Jointed"))},
-	{name: "High Maintenance", cost: -1, isAllowed: andS(includeType("Machine"), excludeTrait("Durable"))},
-	{name: "Uncanny", cost: -1, isAllowed: andS(includeType("Machine"), excludeTrait("Emotion Emulators"))},
-	{name: "Custom-Made", cost: -1, isAllowed: andS(includeType("Machine"), excludeTrait("Mass-Produced"))},
-	{name: "Luxurious", cost: -2, isAllowed: andS(includeType("Machine"), excludeTrait("Recycled"))},
-	{name: "High Bandwidth", cost: -2, isAllowed: andS(includeType("Machine"), excludeTrait("Streamlined Protocols"))},
-	{name: "Learning Algorithms", cost: 1, isAllowed: andS(includeType("Machine"), excludeTrait("Repurposed Hardware"))},
-	{name: "Repurposed Hardware", cost: -1, isAllowed: andS(includeType("Machine"), excludeTrait("Learning Algorithms"))},
-	{name: "Incubators", cost: 2, isAllowed: andS(excludeTrait("Slow Breeders", "Rapid Breeders", "Budding"))},
-	{name: "Noxious", cost: 1, isAllowed: andS(excludeType("Machine"))},
-	{name: "Inorganic Breath", cost: 3, isAllowed: andS(excludeType("Machine"))},
+	{name: "Adaptive", cost: 2, isAllowed: andS(inArchetype("Biological", "Lithoid"), excludeTrait("Extremely Adaptive", "Nonadaptive", "Lithoid", "Spliced Adaptability"))},
+	{name: "Extremely Adaptive", cost: 4, isAllowed: andS(inArchetype("Biological", "Lithoid"), excludeTrait("Adaptive", "Nonadaptive", "Lithoid", "Spliced Adaptability"))},
+	{name: "Agrarian", cost: 2, isAllowed: andS(inArchetype("Biological", "Lithoid"), excludeTrait("Lithoid", "Farm Appendages"))},
+	{name: "Aquatic", cost: 2, isAllowed: andS(inArchetype("Biological", "Lithoid"), excludeTrait("Cave Dweller"))},
+	{name: "Charismatic", cost: 2, isAllowed: andS(inArchetype("Biological", "Lithoid"), excludeTrait("Repugnant", "Crafted Smiles"))},
+	{name: "Communal", cost: 1, isAllowed: andS(inArchetype("Biological", "Lithoid"), excludeTrait("Solitary"))},
+	{name: "Conformists", cost: 2, isAllowed: andS(inArchetype("Biological", "Lithoid"), excludeTrait("Deviants")), nonGestalt: true},
+	{name: "Conservationist", cost: 1, isAllowed: andS(inArchetype("Biological", "Lithoid"), excludeTrait("Wasteful", "Low Maintenance"))},
+	{name: "Docile", cost: 2, isAllowed: andS(inArchetype("Biological", "Lithoid"), excludeTrait("Unruly"))},
+	{name: "Enduring", cost: 1, isAllowed: andS(inArchetype("Biological", "Lithoid"), excludeTrait("Fleeting", "Venerable", "Excessive Endurance"))},
+	{name: "Venerable", cost: 4, isAllowed: andS(inArchetype("Biological", "Lithoid"), excludeTrait("Fleeting", "Enduring", "Excessive Endurance"))},
+	{name: "Industrious", cost: 2, isAllowed: andS(inArchetype("Biological", "Lithoid"), excludeTrait("Dedicated Miner"))},
+	{name: "Ingenious", cost: 2, isAllowed: andS(inArchetype("Biological", "Lithoid"), excludeTrait("Technical Talent"))},
+	{name: "Intelligent", cost: 2, isAllowed: andS(inArchetype("Biological", "Lithoid"), excludeTrait("Serviles", "Augmented Intelligence", "Elevated Synapses"))},
+	{name: "Natural Engineers", cost: 1, isAllowed: andS(inArchetype("Biological", "Lithoid"), excludeTrait("Natural Physicists", "Natural Sociologists", "Serviles"))},
+	{name: "Natural Physicists", cost: 1, isAllowed: andS(inArchetype("Biological", "Lithoid"), excludeTrait("Natural Engineers", "Natural Sociologists", "Serviles"))},
+	{name: "Natural Sociologists", cost: 1, isAllowed: andS(inArchetype("Biological", "Lithoid"), excludeTrait("Natural Engineers", "Natural Physicists", "Serviles"))},
+	{name: "Nomadic", cost: 1, isAllowed: andS(inArchetype("Biological", "Lithoid"), excludeTrait("Sedentary"))},
+	{name: "Quick Learners", cost: 1, isAllowed: andS(inArchetype("Biological", "Lithoid"), excludeTrait("Slow Learners", "Gene Mentorship"))},
+	{name: "Rapid Breeders", cost: 2, isAllowed: andS(inArchetype("Biological", "Lithoid"), excludeTrait("Slow Breeders", "Clone Soldier", "Lithoid", "Pre-Planned Growth"))},
+	{name: "Resilient", cost: 1, isAllowed: andS(inArchetype("Biological", "Lithoid"))},
+	{name: "Strong", cost: 1, isAllowed: andS(inArchetype("Biological", "Lithoid"), excludeTrait("Very Strong", "Weak", "Juiced Power"))},
+	{name: "Very Strong", cost: 3, isAllowed: andS(inArchetype("Biological", "Lithoid"), excludeTrait("Strong", "Weak", "Juiced Power"))},
+	{name: "Talented", cost: 1, isAllowed: andS(inArchetype("Biological", "Lithoid"))},
+	{name: "Thrifty", cost: 2, isAllowed: andS(inArchetype("Biological", "Lithoid")), nonGestalt: true},
+	{name: "Traditional", cost: 1, isAllowed: andS(inArchetype("Biological", "Lithoid"), excludeTrait("Quarrelsome", "Expressed Tradition"))},
+	{name: "Nonadaptive", cost: -2, isAllowed: andS(inArchetype("Biological", "Lithoid"), excludeTrait("Adaptive", "Extremely Adaptive", "Lithoid", "Spliced Adaptability"))},
+	{name: "Repugnant", cost: -2, isAllowed: andS(inArchetype("Biological", "Lithoid"), excludeTrait("Charismatic", "Crafted Smiles"))},
+	{name: "Solitary", cost: -1, isAllowed: andS(inArchetype("Biological", "Lithoid"), excludeTrait("Communal"))},
+	{name: "Deviants", cost: -1, isAllowed: andS(inArchetype("Biological", "Lithoid"), excludeTrait("Conformists")), nonGestalt: true},
+	{name: "Wasteful", cost: -1, isAllowed: andS(inArchetype("Biological", "Lithoid"), excludeTrait("Conservationist", "Low Maintenance"))},
+	{name: "Unruly", cost: -2, isAllowed: andS(inArchetype("Biological", "Lithoid"), excludeTrait("Docile"))},
+	{name: "Fleeting", cost: -1, isAllowed: andS(inArchetype("Biological", "Lithoid"), excludeTrait("Enduring", "Venerable", "Excessive Endurance"))},
+	{name: "Sedentary", cost: -1, isAllowed: andS(inArchetype("Biological", "Lithoid"), excludeTrait("Nomadic"))},
+	{name: "Slow Learners", cost: -1, isAllowed: andS(inArchetype("Biological", "Lithoid"), excludeTrait("Quick Learners", "Gene Mentorship"))},
+	{name: "Slow Breeders", cost: -2, isAllowed: andS(inArchetype("Biological", "Lithoid"), excludeTrait("Rapid Breeders", "Lithoid", "Clone Soldier", "Pre-Planned Growth"))},
+	{name: "Weak", cost: -1, isAllowed: andS(inArchetype("Biological", "Lithoid"), excludeTrait("Strong", "Very Strong", "Juiced Power"))},
+	{name: "Quarrelsome", cost: -1, isAllowed: andS(inArchetype("Biological", "Lithoid"), excludeTrait("Traditional", "Expressed Tradition"))},
+	{name: "Decadent", cost: -1, isAllowed: andS(inArchetype("Biological", "Lithoid")), nonGestalt: true},
+	{name: "Phototropic", cost: 1, isAllowed: andS(includeType(plantPhenotypes...), excludeTrait("Radiotropic", "Cave Dweller"))},
+	{name: "Radiotropic", cost: 2, isAllowed: andS(includeType(plantPhenotypes...), excludeTrait("Phototropic"))},
+	{name: "Budding", cost: 2, isAllowed: andS(includeType(plantPhenotypes...), excludeTrait("Slow Breeders", "Rapid Breeders", "Clone Soldier", "Necrophage", "Pre-Planned Growth"))},
+	{name: "Gaseous Byproducts", cost: 2, isAllowed: andS(inArchetype("Lithoid"), excludeTrait("Scintillating Skin", "Volatile Excretions"))},
+	{name: "Scintillating Skin", cost: 2, isAllowed: andS(inArchetype("Lithoid"), excludeTrait("Gaseous Byproducts", "Volatile Excretions"))},
+	{name: "Volatile Excretions", cost: 2, isAllowed: andS(inArchetype("Lithoid"), excludeTrait("Gaseous Byproducts", "Scintillating Skin"))},
+	{name: "Crystallization", cost: 2, isAllowed: andS(inArchetype("Lithoid"), excludeTrait("Slow Breeders", "Rapid Breeders", "Incubators", "Clone Soldier", "Necrophage", "Pre-Planned Growth"))},
+	{name: "Double Jointed", cost: 1, isAllowed: andS(inArchetype("Machine", "Robot"), excludeTrait("Bulky"))},
+	{name: "Durable", cost: 1, isAllowed: andS(inArchetype("Machine", "Robot"), excludeTrait("High Maintenance"))},
+	{name: "Efficient Processors", cost: 3, isAllowed: andS(inArchetype("Machine", "Robot"))},
+	{name: "Emotion Emulators", cost: 1, isAllowed: andS(inArchetype("Machine", "Robot"), excludeTrait("Uncanny"))},
+	{name: "Enhanced Memory", cost: 2, isAllowed: andS(inArchetype("Machine", "Robot"))},
+	{name: "Logic Engines", cost: 2, isAllowed: andS(inArchetype("Machine", "Robot"))},
+	{name: "Mass-Produced", cost: 1, isAllowed: andS(inArchetype("Machine", "Robot"), excludeTrait("Custom-Made"))},
+	{name: "Power Drills", cost: 2, isAllowed: andS(inArchetype("Machine", "Robot"))},
+	{name: "Recycled", cost: 2, isAllowed: andS(inArchetype("Machine", "Robot"), excludeTrait("Luxurious"))},
+	{name: "Streamlined Protocols", cost: 2, isAllowed: andS(inArchetype("Machine", "Robot"), excludeTrait("High Bandwidth"))},
+	{name: "Superconducive", cost: 2, isAllowed: andS(inArchetype("Machine", "Robot"))},
+	{name: "Bulky", cost: -1, isAllowed: andS(inArchetype("Machine", "Robot"), excludeTrait("Double Jointed"))},
+	{name: "High Maintenance", cost: -1, isAllowed: andS(inArchetype("Machine", "Robot"), excludeTrait("Durable"))},
+	{name: "Uncanny", cost: -1, isAllowed: andS(inArchetype("Machine", "Robot"), excludeTrait("Emotion Emulators"))},
+	{name: "Custom-Made", cost: -1, isAllowed: andS(inArchetype("Machine", "Robot"), excludeTrait("Mass-Produced"))},
+	{name: "Luxurious", cost: -2, isAllowed: andS(inArchetype("Machine", "Robot"), excludeTrait("Recycled"))},
+	{name: "High Bandwidth", cost: -2, isAllowed: andS(inArchetype("Machine", "Robot"), excludeTrait("Streamlined Protocols"))},
+	{name: "Learning Algorithms", cost: 1, isAllowed: andS(inArchetype("Machine", "Robot"), excludeTrait("Repurposed Hardware"))},
+	{name: "Repurposed Hardware", cost: -1, isAllowed: andS(inArchetype("Machine", "Robot"), excludeTrait("Learning Algorithms"))},
+	{name: "Incubators", cost: 2, isAllowed: andS(inArchetype("Biological", "Lithoid"), excludeTrait("Slow Breeders", "Rapid Breeders", "Budding", "Pre-Planned Growth"))},
+	{name: "Noxious", cost: 1, isAllowed: andS(includeType(toxoidPhenotypes...))},
+	{name: "Inorganic Breath", cost: 3, isAllowed: andS(includeType(toxoidPhenotypes...))},
 }
 
+// overtunedTraits are stronger versions of regular traits, only open to empires whose species rules allow them.
+// Every one of them shortens the lifespan of the leaders of the species.
 var overtunedTraits = []Trait{
-	{name: "Augmented Intelligence", cost: 1, isAllowed: sAlways},
-	{name: "Crafted Smiles", cost: 1, isAllowed: sAlways},
-	{name: "Dedicated Miner", cost: 1, isAllowed: sAlways},
-	{name: "Expressed Tradition", cost: 1, isAllowed: sAlways},
-	{name: "Farm Appendages", cost: 1, isAllowed: sAlways},
-	{name: "Gene Mentorship", cost: 1, isAllowed: sAlways},
-	{name: "Juiced Power", cost: 1, isAllowed: sAlways},
-	{name: "Low Maintenance", cost: 1, isAllowed: sAlways, nonGestalt: true},
-	{name: "Spliced Adaptability", cost: 1, isAllowed: sAlways},
-	{name: "Technical Talent", cost: 1, isAllowed: sAlways},
-	{name: "Elevated Synapses", cost: 2, isAllowed: sAlways},
-	{name: "Pre-Planned Growth", cost: 2, isAllowed: sAlways},
-	{name: "Excessive Endurance", cost: 3, isAllowed: sAlways},
-}
-
-func sAlways(s Species) bool {
-	return true
+	{name: "Augmented Intelligence", cost: 1, lifespan: -10, isAllowed: andS(inArchetype("Biological", "Lithoid"), excludeTrait("Intelligent", "Elevated Synapses"))},
+	{name: "Crafted Smiles", cost: 1, lifespan: -10, isAllowed: andS(inArchetype("Biological", "Lithoid"), excludeTrait("Charismatic", "Repugnant"))},
+	{name: "Dedicated Miner", cost: 1, lifespan: -10, isAllowed: andS(inArchetype("Biological", "Lithoid"), excludeTrait("Industrious"))},
+	{name: "Expressed Tradition", cost: 1, lifespan: -10, isAllowed: andS(inArchetype("Biological", "Lithoid"), excludeTrait("Traditional", "Quarrelsome"))},
+	{name: "Farm Appendages", cost: 1, lifespan: -10, isAllowed: andS(inArchetype("Biological"), excludeTrait("Agrarian"))},
+	{name: "Gene Mentorship", cost: 1, lifespan: -10, isAllowed: andS(inArchetype("Biological", "Lithoid"), excludeTrait("Quick Learners", "Slow Learners"))},
+	{name: "Juiced Power", cost: 1, lifespan: -10, isAllowed: andS(inArchetype("Biological", "Lithoid"), excludeTrait("Strong", "Very Strong", "Weak"))},
+	{name: "Low Maintenance", cost: 1, lifespan: -10, isAllowed: andS(inArchetype("Biological", "Lithoid"), excludeTrait("Conservationist", "Wasteful")), nonGestalt: true},
+	{name: "Spliced Adaptability", cost: 1, lifespan: -10, isAllowed: andS(inArchetype("Biological"), excludeTrait("Adaptive", "Extremely Adaptive", "Nonadaptive"))},
+	{name: "Technical Talent", cost: 1, lifespan: -10, isAllowed: andS(inArchetype("Biological", "Lithoid"), excludeTrait("Ingenious"))},
+	{name: "Elevated Synapses", cost: 2, lifespan: -20, isAllowed: andS(inArchetype("Biological"), excludeTrait("Intelligent", "Augmented Intelligence"))},
+	{name: "Pre-Planned Growth", cost: 2, lifespan: -20, isAllowed: andS(inArchetype("Biological", "Lithoid"), excludeTrait("Rapid Breeders", "Slow Breeders", "Budding", "Crystallization", "Incubators", "Clone Soldier", "Necrophage"))},
+	{name: "Excessive Endurance", cost: 3, lifespan: -30, isAllowed: andS(inArchetype("Biological", "Lithoid"), excludeTrait("Enduring", "Venerable", "Fleeting"))},
 }
 
+var sAlways = speciesPredicate{kind: "always"}
+
 var originTraits = make(map[string]Trait)
 
 func init() {
 	originTraits["Lithoid"] = Trait{name: "Lithoid", cost: 0, isAllowed: never}
+	originTraits["Machine"] = Trait{name: "Machine", cost: 0, isAllowed: never}
+	originTraits["Mechanical"] = Trait{name: "Mechanical", cost: 0, isAllowed: never}
+	originTraits["Cyborg"] = Trait{name: "Cyborg", cost: 0, isAllowed: inArchetype("Biological", "Lithoid")}
 	originTraits["Serviles"] = Trait{name: "Serviles", cost: 1, isAllowed: never}
 	originTraits["Clone Soldier"] = Trait{name: "Clone Soldier", cost: 0, isAllowed: never}
 	originTraits["Survivor"] = Trait{name: "Survivor", cost: 0, isAllowed: never}
 	originTraits["Void Dweller"] = Trait{name: "Void Dweller", cost: 0, isAllowed: never}
 	originTraits["Necrophage"] = Trait{name: "Necrophage", cost: 0, isAllowed: never}
 	originTraits["Cave Dweller"] = Trait{name: "Cave Dweller", cost: 0, isAllowed: never}
-	originTraits["Aquatic"] = Trait{name: "Aquatic", cost: 2, isAllowed: andS(excludeType("Machine"), excludeTrait("Cave Dweller"))}
+	originTraits["Aquatic"] = Trait{name: "Aquatic", cost: 2, isAllowed: andS(inArchetype("Biological", "Lithoid"), excludeTrait("Cave Dweller"))}
 }
 
-func never(s Species) bool {
-	return false
-}
+var never = speciesPredicate{kind: "never"}
 
 func auth(s ...string) Predicate {
-	return func(empire Empire) bool {
-		for _, auth := range s {
-			if empire.authority == auth {
-				return true
-			}
-		}
-		return false
-	}
+	return Predicate{kind: "auth", names: s}
 }
 
 func notAuth(s ...string) Predicate {
-	return func(empire Empire) bool {
-		for _, auth := range s {
-			if empire.authority == auth {
+	return Predicate{kind: "notAuth", names: s}
+}
+
+func excludeCivic(s ...string) Predicate {
+	return Predicate{kind: "excludeCivic", names: s}
+}
+
+func includeCivic(s ...string) Predicate {
+	return Predicate{kind: "includeCivic", names: s}
+}
+
+func excludeEthic(s ...string) Predicate {
+	return Predicate{kind: "excludeEthic", names: s}
+}
+
+func includeEthic(s ...string) Predicate {
+	return Predicate{kind: "includeEthic", names: s}
+}
+
+func includeOrigin(s ...string) Predicate {
+	return Predicate{kind: "includeOrigin", names: s}
+}
+
+func and(s ...Predicate) Predicate {
+	return Predicate{kind: "and", parts: s}
+}
+
+// allows is test for an empire that is still being generated: rules asking for
+// a component that is not chosen yet pass, as a later step may still choose it.
+func (p Predicate) allows(empire Empire) bool {
+	switch p.kind {
+	case "auth", "notAuth":
+		if empire.authority == "" {
+			return true
+		}
+	case "includeCivic":
+		if free, _ := civicSlots(empire); len(empire.civics) < free {
+			return true
+		}
+	case "includeEthic":
+		if ethicPoints(empire.ethics) < maxEthicPoints {
+			return true
+		}
+	case "includeOrigin":
+		if empire.origin.name == "" {
+			return true
+		}
+	case "and":
+		for _, pred := range p.parts {
+			if !pred.allows(empire) {
 				return false
 			}
 		}
 		return true
 	}
+	return p.test(empire)
 }
 
-func excludeCivic(s ...string) Predicate {
-	return func(empire Empire) bool {
+func (p Predicate) test(empire Empire) bool {
+	switch p.kind {
+	case "auth":
+		return contains(p.names, empire.authority)
+	case "notAuth":
+		return !contains(p.names, empire.authority)
+	case "excludeCivic":
 		for _, civic := range empire.civics {
-			for _, excluded := range s {
-				if excluded == civic.name {
-					return false
-				}
+			if contains(p.names, civic.name) {
+				return false
 			}
 		}
-		return true
-	}
-}
-
-func excludeEthic(s ...string) Predicate {
-	return func(empire Empire) bool {
+	case "includeCivic":
+		for _, civic := range empire.civics {
+			if contains(p.names, civic.name) {
+				return true
+			}
+		}
+		return false
+	case "excludeEthic":
 		for _, ethic := range empire.ethics {
-			for _, excluded := range s {
-				if excluded == ethic.name {
-					return false
-				}
+			if contains(p.names, ethic.name) {
+				return false
 			}
 		}
-		return true
-	}
-}
-
-func includeEthic(s ...string) Predicate {
-	return func(empire Empire) bool {
+	case "includeEthic":
 		for _, ethic := range empire.ethics {
-			for _, included := range s {
-				if included == ethic.name {
-					return true
-				}
+			if contains(p.names, ethic.name) {
+				return true
 			}
 		}
 		return false
+	case "includeOrigin":
+		return contains(p.names, empire.origin.name)
+	case "onlyGestalt":
+		return len(empire.ethics) == 0
+	case "and":
+		for _, pred := range p.parts {
+			if !pred.test(empire) {
+				return false
+			}
+		}
 	}
+	return true
 }
 
-func and(s ...Predicate) Predicate {
-	return func(empire Empire) bool {
-		for _, pred := range s {
-			if !pred(empire) {
-				return false
+// reason explains why the predicate rejects the empire, or returns an empty string when it does not.
+func (p Predicate) reason(empire Empire) string {
+	if p.kind == "and" {
+		for _, pred := range p.parts {
+			if res := pred.reason(empire); res != "" {
+				return res
 			}
 		}
-		return true
+		return ""
 	}
+	if p.test(empire) {
+		return ""
+	}
+	switch p.kind {
+	case "auth":
+		return "requires authority " + strings.Join(p.names, " or ")
+	case "notAuth":
+		return "not available to " + empire.authority
+	case "excludeCivic":
+		for _, civic := range empire.civics {
+			if contains(p.names, civic.name) {
+				return "excluded by civic " + civic.name
+			}
+		}
+	case "excludeEthic":
+		for _, ethic := range empire.ethics {
+			if contains(p.names, ethic.name) {
+				return "excluded by ethic " + ethic.name
+			}
+		}
+	case "includeCivic":
+		return "requires civic " + strings.Join(p.names, " or ")
+	case "includeEthic":
+		return "requires ethic " + strings.Join(p.names, " or ")
+	case "includeOrigin":
+		return "requires origin " + strings.Join(p.names, " or ")
+	case "onlyGestalt":
+		return "only available without other ethics"
+	}
+	return "not allowed"
 }
 
-func excludeTrait(s ...string) speciesPredicate {
-	return func(species Species) bool {
-		for _, trait := range s {
-			for _, sTrait := range species.traits {
-				if trait == sTrait.name {
-					return false
-				}
-			}
+// rules describes the predicate, one line per rule, such as "requires ethic Militarist or Fanatic Militarist".
+// A predicate that allows everything has no rules.
+func (p Predicate) rules() []string {
+	switch p.kind {
+	case "and":
+		res := []string{}
+		for _, pred := range p.parts {
+			res = append(res, pred.rules()...)
 		}
-		return true
+		return res
+	case "auth":
+		return []string{"requires authority " + strings.Join(p.names, " or ")}
+	case "notAuth":
+		return []string{"not available to " + strings.Join(p.names, " or ")}
+	case "excludeCivic":
+		return []string{"excluded by civic " + strings.Join(p.names, " or ")}
+	case "includeCivic":
+		return []string{"requires civic " + strings.Join(p.names, " or ")}
+	case "excludeEthic":
+		return []string{"excluded by ethic " + strings.Join(p.names, " or ")}
+	case "includeEthic":
+		return []string{"requires ethic " + strings.Join(p.names, " or ")}
+	case "includeOrigin":
+		return []string{"requires origin " + strings.Join(p.names, " or ")}
+	case "onlyGestalt":
+		return []string{"only available without other ethics"}
 	}
+	return nil
+}
+
+func excludeTrait(s ...string) speciesPredicate {
+	return speciesPredicate{kind: "excludeTrait", names: s}
+}
+
+func includeTrait(s ...string) speciesPredicate {
+	return speciesPredicate{kind: "includeTrait", names: s}
 }
 
 func includeType(s ...string) speciesPredicate {
-	return func(species Species) bool {
-		for _, popType := range s {
-			if species.popType == popType {
+	return speciesPredicate{kind: "includeType", names: s}
+}
+
+func excludeType(s ...string) speciesPredicate {
+	return speciesPredicate{kind: "excludeType", names: s}
+}
+
+func inArchetype(s ...string) speciesPredicate {
+	return speciesPredicate{kind: "inArchetype", names: s}
+}
+
+func andS(s ...speciesPredicate) speciesPredicate {
+	return speciesPredicate{kind: "and", parts: s}
+}
+
+func (p speciesPredicate) test(species Species) bool {
+	switch p.kind {
+	case "excludeTrait":
+		for _, sTrait := range species.traits {
+			if contains(p.names, sTrait.name) {
+				return false
+			}
+		}
+	case "includeTrait":
+		for _, sTrait := range species.traits {
+			if contains(p.names, sTrait.name) {
 				return true
 			}
 		}
 		return false
+	case "includeType":
+		return contains(p.names, species.popType)
+	case "excludeType":
+		return !contains(p.names, species.popType)
+	case "inArchetype":
+		return contains(p.names, archetypeOf(species.popType).name)
+	case "never":
+		return false
+	case "and":
+		for _, pred := range p.parts {
+			if !pred.test(species) {
+				return false
+			}
+		}
 	}
+	return true
 }
 
-func excludeType(s ...string) speciesPredicate {
-	return func(species Species) bool {
-		for _, popType := range s {
-			if species.popType == popType {
-				return false
+// reason explains why the predicate rejects the species, or returns an empty string when it does not.
+func (p speciesPredicate) reason(species Species) string {
+	if p.kind == "and" {
+		for _, pred := range p.parts {
+			if res := pred.reason(species); res != "" {
+				return res
 			}
 		}
-		return true
+		return ""
+	}
+	if p.test(species) {
+		return ""
+	}
+	switch p.kind {
+	case "excludeTrait":
+		for _, sTrait := range species.traits {
+			if contains(p.names, sTrait.name) {
+				return "excluded by trait " + sTrait.name
+			}
+		}
+	case "includeTrait":
+		return "requires trait " + strings.Join(p.names, " or ")
+	case "includeType":
+		return "requires pop type " + strings.Join(p.names, " or ")
+	case "excludeType":
+		return "not available to " + species.popType + " species"
+	case "inArchetype":
+		return "not available to " + archetypeOf(species.popType).name + " species"
+	case "never":
+		return "only granted by origins and civics"
 	}
+	return "not allowed"
 }
 
-func andS(s ...speciesPredicate) speciesPredicate {
-	return func(species Species) bool {
-		for _, pred := range s {
-			if !pred(species) {
-				return false
-			}
+// rules describes the predicate like Predicate.rules.
+func (p speciesPredicate) rules() []string {
+	switch p.kind {
+	case "and":
+		res := []string{}
+		for _, pred := range p.parts {
+			res = append(res, pred.rules()...)
 		}
-		return true
+		return res
+	case "excludeTrait":
+		return []string{"excluded by trait " + strings.Join(p.names, " or ")}
+	case "includeTrait":
+		return []string{"requires trait " + strings.Join(p.names, " or ")}
+	case "includeType":
+		return []string{"requires pop type " + strings.Join(p.names, " or ")}
+	case "excludeType":
+		return []string{"not available to " + strings.Join(p.names, " or ") + " species"}
+	case "inArchetype":
+		return []string{"requires archetype " + strings.Join(p.names, " or ")}
+	case "never":
+		return []string{"only granted by origins and civics"}
 	}
+	return nil
+}
+
+func contains(list []string, name string) bool {
+	for _, item := range list {
+		if item == name {
+			return true
+		}
+	}
+	return false
 }
@@ -0,0 +1,185 @@
+package main
+
+import (
+	"encoding/csv"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+
+	"github.com/maxence-charriere/go-app/v9/pkg/app"
+)
+
+// matrixCell is one cell of the compatibility matrix: an item under an authority and one of the ethics.
+type matrixCell struct {
+	allowed bool
+	reason  string // why the cell is blocked
+}
+
+// evaluateCell tests the item of the catalogue against the authority and every legal set of ethics that includes the ethic.
+// The cell is allowed when one of them satisfies the rules of the authority, the ethics and the item.
+// Otherwise the reason names the rule of the item that fails, or that of the authority when no
+// set of these ethics suits it at all.
+func evaluateCell(c *catalogue, ref catalogueRef, auth string, ethic string) matrixCell {
+	authReason, itemReason := "", ""
+	for _, ethics := range ethicSets() {
+		if !contains(ethicNames(ethics), ethic) {
+			continue
+		}
+		base := Empire{authority: auth, ethics: ethics, version: c.version}
+		if problem := partialProblem(base); problem != "" {
+			if authReason == "" {
+				authReason = problem
+			}
+			continue
+		}
+		for _, empire := range withItem(base, ref) {
+			problem := partialProblem(empire)
+			if problem == "" {
+				return matrixCell{allowed: true}
+			}
+			if itemReason == "" {
+				itemReason = problem
+			}
+		}
+	}
+	switch {
+	case itemReason != "":
+		return matrixCell{reason: itemReason}
+	case authReason != "":
+		return matrixCell{reason: authReason}
+	}
+	return matrixCell{reason: "no legal set of ethics includes " + ethic}
+}
+
+// matrixRows are the items the matrix tests: every civic and origin of the catalogue, each name once.
+func matrixRows(c *catalogue, kind string) []catalogueRef {
+	res := []catalogueRef{}
+	for _, entry := range catalogueEntries(c) {
+		if entry.kind == kind {
+			res = append(res, catalogueRef{kind: entry.kind, name: entry.name})
+		}
+	}
+	return res
+}
+
+// ethicLabels shorten the ethics whose first letters are not enough.
+var ethicLabels = map[string]string{"Xenophobe": "Phob", "Xenophile": "Phil"}
+
+// ethicLabel shortens an ethic for a column header, such as "FMil" for Fanatic Militarist.
+func ethicLabel(name string) string {
+	ethic := ethicByName(name)
+	label, ok := ethicLabels[ethic.regularName()]
+	if !ok {
+		label = ethic.regularName()[:3]
+	}
+	if ethic.regular != "" {
+		label = "F" + label
+	}
+	return label
+}
+
+// matrixView shows which civics or origins are legal under every authority and ethic.
+// Hovering a blocked cell shows the rule that blocks it.
+type matrixView struct {
+	app.Compo
+	kind      string
+	authority string
+}
+
+func (v *matrixView) Render() app.UI {
+	if v.kind == "" {
+		v.kind = "civic"
+	}
+	c := latestCatalogue
+	authorities := []string{}
+	for _, auth := range c.authorities {
+		if v.authority == "" || auth.name == v.authority {
+			authorities = append(authorities, auth.name)
+		}
+	}
+	ethics := ethicOptions()
+	rows := matrixRows(c, v.kind)
+	return app.Div().Body(
+		app.A().Href("/").Text("Random empires"),
+		app.Text(" "),
+		app.A().Href("/catalogue").Text("Catalogue"),
+		app.H3().Text("Compatibility matrix "+c.version),
+		app.Label().Text("Rows:").For("matrixKind"),
+		app.Select().ID("matrixKind").OnChange(v.ValueTo(&v.kind)).Body(
+			app.Option().Value("civic").Text("civics").Selected(v.kind == "civic"),
+			app.Option().Value("origin").Text("origins").Selected(v.kind == "origin"),
+		),
+		app.Label().Text("Authority:").For("matrixAuthority"),
+		app.Select().ID("matrixAuthority").OnChange(v.ValueTo(&v.authority)).Body(
+			app.Option().Value("").Text("all"),
+			app.Range(c.authorities).Slice(func(i int) app.UI {
+				return app.Option().Value(c.authorities[i].name).Text(c.authorities[i].name).Selected(c.authorities[i].name == v.authority)
+			}),
+		),
+		app.Table().Class("matrix").Body(
+			app.Tr().Body(
+				app.Th(),
+				app.Range(authorities).Slice(func(i int) app.UI {
+					return app.Th().ColSpan(len(ethics)).Text(authorities[i])
+				}),
+			),
+			app.Tr().Body(
+				app.Th(),
+				app.Range(authorities).Slice(func(i int) app.UI {
+					return app.Range(ethics).Slice(func(j int) app.UI {
+						return app.Th().Title(ethics[j]).Text(ethicLabel(ethics[j]))
+					})
+				}),
+			),
+			app.Range(rows).Slice(func(i int) app.UI {
+				return app.Tr().Body(
+					app.Th().Body(app.A().Href(itemPath(rows[i])).Text(rows[i].name)),
+					app.Range(authorities).Slice(func(j int) app.UI {
+						return app.Range(ethics).Slice(func(k int) app.UI {
+							cell := evaluateCell(c, rows[i], authorities[j], ethics[k])
+							if cell.allowed {
+								return app.Td().Class("allowed").Title(rows[i].name + " with " + authorities[j] + " and " + ethics[k]).Text("✓")
+							}
+							return app.Td().Class("blocked").Title(cell.reason)
+						})
+					}),
+				)
+			}),
+		),
+	)
+}
+
+// writeMatrixCSV writes a line per cell of the matrices of civics and origins of the catalogue.
+func writeMatrixCSV(w io.Writer, c *catalogue) error {
+	out := csv.NewWriter(w)
+	out.Write([]string{"kind", "name", "authority", "ethic", "allowed", "reason"})
+	for _, kind := range []string{"civic", "origin"} {
+		for _, ref := range matrixRows(c, kind) {
+			for _, auth := range c.authorities {
+				for _, ethic := range ethicOptions() {
+					cell := evaluateCell(c, ref, auth.name, ethic)
+					out.Write([]string{ref.kind, ref.name, auth.name, ethic, fmt.Sprint(cell.allowed), cell.reason})
+				}
+			}
+		}
+	}
+	out.Flush()
+	return out.Error()
+}
+
+// runMatrix prints the compatibility matrix as CSV.
+func runMatrix(args []string) {
+	flags := flag.NewFlagSet("matrix", flag.ExitOnError)
+	version := flags.String("game-version", latestVersion, "catalogue version to evaluate, see generate")
+	flags.Parse(args)
+	c, err := catalogueFor(*version)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(1)
+	}
+	if err := writeMatrixCSV(os.Stdout, c); err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(1)
+	}
+}
@@ -0,0 +1,150 @@
+package main
+
+import (
+	"math/rand"
+	"strings"
+)
+
+// names are generated from the name seed of the empire and its components only,
+// so an empire restored from a share code gets the same names again.
+type names struct {
+	empire    string
+	species   string
+	plural    string
+	adjective string
+	homeworld string
+}
+
+// phonetics are the building blocks for the names of one pop type.
+type phonetics struct {
+	onsets []string
+	vowels []string
+	codas  []string
+}
+
+var popTypePhonetics = map[string]phonetics{
+	"Mammalian":  {onsets: []string{"b", "d", "f", "h", "m", "n", "p", "r", "t", "v"}, vowels: []string{"a", "e", "i", "o", "u"}, codas: []string{"", "", "n", "r", "l", "m"}},
+	"Reptilian":  {onsets: []string{"s", "z", "th", "k", "sk", "x", "ss"}, vowels: []string{"a", "i", "e", "y"}, codas: []string{"", "s", "ss", "th", "k", "x"}},
+	"Avian":      {onsets: []string{"k", "r", "kr", "tr", "qu", "t", "p"}, vowels: []string{"ee", "a", "i", "ii", "aa"}, codas: []string{"", "", "k", "r", "t"}},
+	"Arthropoid": {onsets: []string{"k", "x", "t", "ch", "tch", "kr", "q"}, vowels: []string{"a", "i", "e", "u"}, codas: []string{"k", "x", "t", "tch", "'k"}},
+	"Molluscoid": {onsets: []string{"gl", "bl", "m", "n", "ul", "sl", "w"}, vowels: []string{"u", "oo", "o", "ua"}, codas: []string{"", "b", "m", "l", "rb"}},
+	"Fungoid":    {onsets: []string{"sp", "m", "f", "g", "spr", "b", "n"}, vowels: []string{"o", "u", "oo", "a"}, codas: []string{"", "m", "g", "r", "ss"}},
+	"Plantoid":   {onsets: []string{"l", "v", "f", "s", "th", "y", "w"}, vowels: []string{"a", "i", "ae", "ia", "e"}, codas: []string{"", "", "l", "n", "th"}},
+	"Lithoid":    {onsets: []string{"g", "gr", "d", "dr", "k", "kh", "t"}, vowels: []string{"o", "a", "u", "ou"}, codas: []string{"", "g", "d", "rk", "th", "n"}},
+	"Necroid":    {onsets: []string{"m", "v", "n", "th", "z", "mor", "x"}, vowels: []string{"o", "e", "a", "u"}, codas: []string{"", "th", "x", "r", "z", "n"}},
+	"Aquatic":    {onsets: []string{"l", "w", "sh", "m", "n", "y", "h"}, vowels: []string{"oo", "ai", "a", "i", "ou"}, codas: []string{"", "", "l", "sh", "n"}},
+	"Toxoid":     {onsets: []string{"z", "x", "ch", "gr", "sk", "v", "k"}, vowels: []string{"a", "u", "o", "y"}, codas: []string{"", "z", "x", "g", "ch"}},
+	"Machine":    {onsets: []string{"ax", "syn", "cor", "dyn", "omn", "qu", "v", "t"}, vowels: []string{"i", "o", "e", "a"}, codas: []string{"on", "tron", "ex", "ix", "um", "yr"}},
+}
+
+// ethicPrefixes are words an empire name may start with, per ethic regardless of fanaticism.
+var ethicPrefixes = map[string][]string{
+	"Authoritarian": {"Sovereign", "Supreme", "Exalted"},
+	"Egalitarian":   {"Free", "United", "People's"},
+	"Militarist":    {"Martial", "Iron", "Warlike"},
+	"Pacifist":      {"Peaceful", "Harmonious", "Serene"},
+	"Spiritualist":  {"Holy", "Sacred", "Blessed"},
+	"Materialist":   {"Rational", "Enlightened", "Progressive"},
+	"Xenophile":     {"Allied", "Cosmopolitan", "Open"},
+	"Xenophobe":     {"Pure", "Ascendant", "Unbroken"},
+}
+
+// governmentNouns are the nouns an empire name may end with, per form of government.
+// Forms without an entry use the last word of their name.
+var governmentNouns = map[string][]string{
+	"Democratic Republic":     {"Republic", "Commonwealth", "Federation"},
+	"Oligarchic Republic":     {"Republic", "Directorate", "Assembly"},
+	"Dictatorship":            {"State", "Dominion", "Autocracy"},
+	"Star Empire":             {"Empire", "Star Empire", "Imperium"},
+	"Megacorporation":         {"Corporation", "Consortium", "Conglomerate", "Trade League"},
+	"Criminal Syndicate":      {"Syndicate", "Cartel", "Family"},
+	"Megachurch":              {"Megachurch", "Congregation"},
+	"Hive Mind":               {"Hive", "Brood", "Swarm", "Collective"},
+	"Devouring Swarm":         {"Swarm", "Devourers", "Horde"},
+	"Terravore Hive":          {"Terravores", "Eaters", "Hive"},
+	"Machine Intelligence":    {"Network", "Consciousness", "Intelligence", "Nexus"},
+	"Determined Exterminator": {"Purge Protocol", "Extermination Engine", "Cleansing Directive"},
+	"Driven Assimilator":      {"Assimilation Network", "Cybernetic Union", "Integration Nexus"},
+	"Rogue Servitor":          {"Caretakers", "Custodians", "Bio-Trophy Preserve"},
+	"Feudal Realm":            {"Realm", "Kingdom", "Dominions"},
+	"Divine Empire":           {"Divine Empire", "Holy Empire", "Celestial Throne"},
+	"Theocratic Oligarchy":    {"Theocracy", "Holy Council", "Sacred Order"},
+	"Science Directorate":     {"Directorate", "Academy", "Institute"},
+}
+
+// generateNames builds all names of the empire with its own random source, leaving the generator untouched.
+func generateNames(empire Empire) names {
+	rng := rand.New(rand.NewSource(empire.nameSeed))
+	sounds := phoneticsOf(empire.mainSpecies.popType)
+	root := word(rng, sounds, 2)
+	res := names{species: root, plural: plural(root), adjective: adjective(root)}
+	res.homeworld = word(rng, sounds, 2+rng.Intn(2))
+	if rng.Intn(3) == 0 {
+		res.homeworld += []string{" Prime", " Major", " Secundus"}[rng.Intn(3)]
+	}
+
+	government := governmentName(empire)
+	nouns, ok := governmentNouns[government]
+	if !ok {
+		words := strings.Fields(government)
+		nouns = []string{words[len(words)-1]}
+	}
+	noun := nouns[rng.Intn(len(nouns))]
+	prefixes := []string{}
+	for _, ethic := range empire.ethics {
+		prefixes = append(prefixes, ethicPrefixes[ethic.regularName()]...)
+	}
+	switch {
+	case len(prefixes) > 0 && rng.Intn(3) == 0:
+		res.empire = prefixes[rng.Intn(len(prefixes))] + " " + res.adjective + " " + noun
+	case rng.Intn(2) == 0:
+		res.empire = noun + " of " + res.homeworld
+	default:
+		res.empire = res.adjective + " " + noun
+	}
+	if !strings.HasPrefix(res.empire, "The ") && strings.HasSuffix(noun, "s") && rng.Intn(2) == 0 {
+		res.empire = "The " + res.empire
+	}
+	return res
+}
+
+func phoneticsOf(popType string) phonetics {
+	if sounds, ok := popTypePhonetics[popType]; ok {
+		return sounds
+	}
+	return popTypePhonetics["Mammalian"]
+}
+
+// word strings syllables together and capitalises the result.
+func word(rng *rand.Rand, sounds phonetics, syllables int) string {
+	res := ""
+	for i := 0; i < syllables; i++ {
+		res += sounds.onsets[rng.Intn(len(sounds.onsets))] + sounds.vowels[rng.Intn(len(sounds.vowels))]
+		if i == syllables-1 || rng.Intn(4) == 0 {
+			res += sounds.codas[rng.Intn(len(sounds.codas))]
+		}
+	}
+	return strings.ToUpper(res[:1]) + res[1:]
+}
+
+func plural(singular string) string {
+	switch {
+	case strings.HasSuffix(singular, "s"), strings.HasSuffix(singular, "x"), strings.HasSuffix(singular, "z"),
+		strings.HasSuffix(singular, "sh"), strings.HasSuffix(singular, "ch"):
+		return singular + "es"
+	case strings.HasSuffix(singular, "'k"):
+		return singular + "i"
+	}
+	return singular + "s"
+}
+
+func adjective(singular string) string {
+	last := singular[len(singular)-1:]
+	switch {
+	case strings.Contains("aeiouy", last):
+		return singular + "n"
+	case strings.HasSuffix(singular, "on"), strings.HasSuffix(singular, "ex"), strings.HasSuffix(singular, "ix"):
+		return singular + "ic"
+	}
+	return singular + "ian"
+}
@@ -0,0 +1,119 @@
+package main
+
+import (
+	"fmt"
+	"math/rand"
+	"strings"
+)
+
+// traitSamples is the number of accepted species rolled to estimate how likely each trait was.
+const traitSamples = 500
+
+// maxSampleTries bounds the rolls for one trait, as species with a tight budget reject most of them.
+const maxSampleTries = 100 * traitSamples
+
+// odds holds the probability of every choice, given the choices made before it.
+type odds struct {
+	authority  float64
+	ethics     []float64 // same order as Empire.ethics
+	civics     []float64 // same order as Empire.civics
+	origin     float64
+	homeplanet float64
+	predicted  float64
+	mainTraits map[string]float64
+	subTraits  map[string]float64
+}
+
+func drawChance(ethicList []Ethic, name string) float64 {
+	count := 0
+	for _, ethic := range ethicList {
+		if ethic.name == name {
+			count++
+		}
+	}
+	return float64(count) / float64(len(ethicList))
+}
+
+// estimateTraitOdds rolls the species of the empire again to find how likely each of its traits was.
+// Traits are drawn with rejection sampling until the points add up, so there is no closed form.
+// It is only called for empires that are shown, as it takes a few thousand rolls, and it uses its
+// own random source, seeded by the empire, so it does not change what the generator rolls next.
+func estimateTraitOdds(empire Empire) Empire {
+	gestalt := empire.authority == "Hive Mind"
+	rng := rand.New(rand.NewSource(empire.nameSeed))
+	empire.odds.mainTraits = sampleTraits(empire.mainBase, empire.mainSpecies, gestalt, rng)
+	if len(empire.subSpecies.traits) > 0 {
+		empire.odds.subTraits = sampleTraits(empire.subBase, empire.subSpecies, gestalt, rng)
+	}
+	return empire
+}
+
+// sampleTraits estimates the chance of every drawn trait given the traits drawn before it: it keeps those,
+// finishes the species like singleSpeciesTry would and counts how often the accepted species have the trait
+// in its place. Decoded empires carry no base species and get no trait odds.
+func sampleTraits(base Species, rolled Species, gestalt bool, rng *rand.Rand) map[string]float64 {
+	res := map[string]float64{}
+	if base.popType == "" || len(rolled.traits) < len(base.traits) {
+		return res
+	}
+	prefix := base
+	for k, trait := range rolled.traits[len(base.traits):] {
+		counts := []int{} // counts that draw a trait in this place
+		for _, count := range traitCounts(base) {
+			if count > k {
+				counts = append(counts, count)
+			}
+		}
+		// the rolled species counts as a sample too, so none of its traits ends up at zero
+		hits, accepted := 1, 1
+		for try := 0; try < maxSampleTries && accepted <= traitSamples; try++ {
+			s, ok := finishTraits(prefix, base, counts[rng.Intn(len(counts))], gestalt, rng)
+			if !ok {
+				continue
+			}
+			accepted++
+			if s.traits[len(prefix.traits)].name == trait.name {
+				hits++
+			}
+		}
+		res[trait.name] = float64(hits) / float64(accepted)
+		prefix.traits = append(prefix.traits, trait)
+	}
+	return res
+}
+
+// finishTraits draws traits until the species has count random traits, and tells whether the budget accepts it.
+func finishTraits(s Species, base Species, count int, gestalt bool, rng *rand.Rand) (Species, bool) {
+	s.traits = append([]Trait{}, s.traits...)
+	for len(s.traits)-len(base.traits) < count {
+		traits := availableTraits(s, gestalt, nil)
+		if len(traits) == 0 {
+			return s, false
+		}
+		s.traits = append(s.traits, traits[rng.Intn(len(traits))])
+	}
+	return s, budgetProblem(base, s) == ""
+}
+
+func withChance(name string, chance float64) string {
+	if chance <= 0 {
+		return name
+	}
+	return fmt.Sprintf("%s (%.3g%%)", name, chance*100)
+}
+
+func (e Empire) ethicsText() string {
+	res := []string{}
+	for i, ethic := range e.ethics {
+		res = append(res, withChance(ethic.name, e.odds.ethics[i]))
+	}
+	return strings.Join(res, ", ")
+}
+
+func (e Empire) civicsText() string {
+	res := []string{}
+	for i, civic := range e.civics {
+		res = append(res, withChance(civic.name, e.odds.civics[i]))
+	}
+	return strings.Join(res, ", ")
+}
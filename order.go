@@ -0,0 +1,110 @@
+package main
+
+import (
+	"fmt"
+	"strings"
+)
+
+// generationSteps are the steps of fillEmpire that may run in any order. Every step filters its
+// candidates with partialProblem, so it respects whatever the steps before it chose.
+var generationSteps = map[string]func(Empire) Empire{
+	"ethics":    chooseEthic,
+	"authority": chooseAuthority,
+	"civics":    fillCivicSlots,
+	"origin":    chooseOrigin,
+}
+
+// defaultOrder is the order of the generation steps unless the empire asks for another one.
+var defaultOrder = []string{"ethics", "authority", "civics", "origin"}
+
+// randomOrder shuffles the generation steps of every empire.
+const randomOrder = "random"
+
+// parseOrder reads a comma separated list of generation steps such as "origin,civics". Steps that are
+// not listed follow in the default order, so "origin" alone starts with the origin. The second result
+// is true for randomOrder.
+func parseOrder(s string) ([]string, bool, error) {
+	s = strings.TrimSpace(s)
+	switch s {
+	case "":
+		return nil, false, nil
+	case randomOrder:
+		return nil, true, nil
+	}
+	res := []string{}
+	for _, part := range strings.Split(s, ",") {
+		name := strings.TrimSpace(part)
+		if _, ok := generationSteps[name]; !ok {
+			return nil, false, fmt.Errorf("unknown generation step %q, use ethics, authority, civics or origin", name)
+		}
+		if contains(res, name) {
+			return nil, false, fmt.Errorf("generation step %q listed twice", name)
+		}
+		res = append(res, name)
+	}
+	for _, name := range defaultOrder {
+		if !contains(res, name) {
+			res = append(res, name)
+		}
+	}
+	return res, false, nil
+}
+
+// stepOrder is the order the generation steps of the empire run in.
+func stepOrder(empire Empire) []string {
+	if empire.shuffleOrder {
+		res := append([]string{}, defaultOrder...)
+		r.Shuffle(len(res), func(i, j int) { res[i], res[j] = res[j], res[i] })
+		return res
+	}
+	if empire.order != nil {
+		return empire.order
+	}
+	return defaultOrder
+}
+
+// runSteps runs the generation steps in the given order, see stepOrder. Steps cannot look ahead, so an early
+// pick may leave a later step without candidates; it then returns false and fillEmpire starts over, like fillSpecies.
+func runSteps(empire Empire, order []string) (Empire, bool) {
+	if step := empire.trace.begin("order", ""); step != nil {
+		step.Drawn = strings.Join(order, ", ")
+	}
+	for _, name := range order {
+		empire = generationSteps[name](empire)
+	}
+	free, _ := civicSlots(empire)
+	switch {
+	case ethicPoints(empire.ethics) < maxEthicPoints, empire.authority == "", empire.origin.name == "", len(empire.civics) < free:
+		return empire, false
+	}
+	return empire, empireProblem(empire) == ""
+}
+
+// partialProblem returns the first rule an empire that is still being generated breaks, or an empty
+// string. Every chosen component is tested against all others, and rules asking for a component
+// that is not chosen yet pass, as a later step may still choose it.
+func partialProblem(empire Empire) string {
+	for i, ethic := range empire.ethics {
+		others := empire
+		others.ethics = append(append([]Ethic{}, empire.ethics[:i]...), empire.ethics[i+1:]...)
+		if !ethic.isAllowed.allows(others) {
+			return ethic.name + ": " + ethic.isAllowed.reason(others)
+		}
+	}
+	for _, auth := range empire.catalogue().authorities {
+		if auth.name == empire.authority && !auth.isAllowed.allows(empire) {
+			return auth.name + ": " + auth.isAllowed.reason(empire)
+		}
+	}
+	for i, civic := range empire.civics {
+		others := empire
+		others.civics = append(append([]Civic{}, empire.civics[:i]...), empire.civics[i+1:]...)
+		if !civic.isAllowed.allows(others) {
+			return civic.name + ": " + civic.isAllowed.reason(others)
+		}
+	}
+	if empire.origin.name != "" && !empire.origin.isAllowed.allows(empire) {
+		return empire.origin.name + ": " + empire.origin.isAllowed.reason(empire)
+	}
+	return ""
+}
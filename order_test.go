@@ -0,0 +1,105 @@
+package main
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestParseOrder(t *testing.T) {
+	tests := []struct {
+		in      string
+		order   []string
+		shuffle bool
+		err     string
+	}{
+		{in: "", order: nil},
+		{in: "  ", order: nil},
+		{in: randomOrder, shuffle: true},
+		{in: "origin", order: []string{"origin", "ethics", "authority", "civics"}},
+		{in: " civics , ethics ", order: []string{"civics", "ethics", "authority", "origin"}},
+		{in: "origin,civics,authority,ethics", order: []string{"origin", "civics", "authority", "ethics"}},
+		{in: "species", err: "unknown generation step"},
+		{in: "origin,origin", err: "listed twice"},
+		{in: "origin,", err: "unknown generation step"},
+	}
+	for _, test := range tests {
+		order, shuffle, err := parseOrder(test.in)
+		if test.err != "" {
+			if err == nil || !strings.Contains(err.Error(), test.err) {
+				t.Errorf("parseOrder(%q): got error %v, want %q", test.in, err, test.err)
+			}
+			continue
+		}
+		if err != nil {
+			t.Errorf("parseOrder(%q): %v", test.in, err)
+			continue
+		}
+		if !reflect.DeepEqual(order, test.order) || shuffle != test.shuffle {
+			t.Errorf("parseOrder(%q) = %v, %v, want %v, %v", test.in, order, shuffle, test.order, test.shuffle)
+		}
+	}
+}
+
+func ethics(names ...string) []Ethic {
+	res := []Ethic{}
+	for _, name := range names {
+		res = append(res, ethicByName(name))
+	}
+	return res
+}
+
+func TestPredicateAllows(t *testing.T) {
+	tests := []struct {
+		name   string
+		pred   Predicate
+		empire Empire
+		want   bool
+	}{
+		{"authority not chosen yet", auth("Imperial"), Empire{}, true},
+		{"authority chosen", auth("Imperial"), Empire{authority: "Imperial"}, true},
+		{"other authority chosen", auth("Imperial"), Empire{authority: "Democratic"}, false},
+		{"excluded authority not chosen yet", notAuth("Hive Mind"), Empire{}, true},
+		{"excluded authority chosen", notAuth("Hive Mind"), Empire{authority: "Hive Mind"}, false},
+		{"ethic points left", includeEthic("Materialist"), Empire{ethics: ethics("Militarist")}, true},
+		{"ethic chosen", includeEthic("Materialist"), Empire{ethics: ethics("Materialist", "Militarist", "Xenophobe")}, true},
+		{"no ethic points left", includeEthic("Materialist"), Empire{ethics: ethics("Fanatic Militarist", "Xenophobe")}, false},
+		{"excluded ethic", excludeEthic("Xenophobe"), Empire{ethics: ethics("Xenophobe")}, false},
+		{"origin not chosen yet", includeOrigin("Void Dwellers"), Empire{}, true},
+		{"other origin chosen", includeOrigin("Void Dwellers"), Empire{origin: Origin{name: "Prosperous Unification"}}, false},
+		{"civic slots left", includeCivic("Technocracy"), Empire{}, true},
+		{"excluded civic", excludeCivic("Technocracy"), Empire{civics: []Civic{{name: "Technocracy"}}}, false},
+		{"every part allows", and(auth("Imperial"), includeEthic("Militarist")), Empire{ethics: ethics("Militarist")}, true},
+		{"one part does not", and(auth("Imperial"), includeEthic("Militarist")), Empire{authority: "Democratic", ethics: ethics("Militarist")}, false},
+	}
+	for _, test := range tests {
+		if got := test.pred.allows(test.empire); got != test.want {
+			t.Errorf("%s: allows = %v, want %v", test.name, got, test.want)
+		}
+	}
+}
+
+func TestPartialProblem(t *testing.T) {
+	c := latestCatalogue
+	tests := []struct {
+		name   string
+		empire Empire
+		want   string // prefix of the problem, empty when there is none
+	}{
+		{"empty empire", Empire{}, ""},
+		{"authority and ethics agree", Empire{authority: "Imperial", ethics: ethics("Authoritarian")}, ""},
+		{"authority excludes an ethic", Empire{authority: "Democratic", ethics: ethics("Authoritarian")}, "Democratic: excluded by ethic Authoritarian"},
+		{"civic waits for its ethic", Empire{authority: "Democratic", civics: []Civic{c.civic("Technocracy")}, ethics: ethics("Militarist")}, ""},
+		{"civic misses its ethic", Empire{authority: "Democratic", civics: []Civic{c.civic("Technocracy")}, ethics: ethics("Fanatic Militarist", "Xenophobe")}, "Technocracy: "},
+		{"civic under the wrong authority", Empire{authority: "Hive Mind", civics: []Civic{c.civic("Technocracy")}}, "Technocracy: requires authority"},
+		{"civics exclude each other", Empire{authority: "Oligarchy", civics: []Civic{c.civic("Technocracy"), c.civic("Exalted Priesthood")}}, "Technocracy: excluded by civic Exalted Priesthood"},
+		{"origin waits for its civics", Empire{origin: c.origin("Remnants")}, ""},
+		{"origin excluded by a civic", Empire{civics: []Civic{c.civic("Agrarian Idyll")}, origin: c.origin("Remnants")}, "Remnants: excluded by civic Agrarian Idyll"},
+	}
+	for _, test := range tests {
+		got := partialProblem(test.empire)
+		if (test.want == "") != (got == "") || !strings.HasPrefix(got, test.want) {
+			t.Errorf("%s: partialProblem = %q, want %q", test.name, got, test.want)
+		}
+	}
+}
@@ -0,0 +1,263 @@
+package main
+
+import (
+	"encoding/json"
+	"flag"
+	"fmt"
+	"os"
+	"strings"
+)
+
+type jsonEmpire struct {
+	Player      string       `json:"player,omitempty"`
+	Name        string       `json:"name"`
+	Species     jsonNames    `json:"species"`
+	Homeworld   string       `json:"homeworld"`
+	NameSeed    int64        `json:"nameSeed"`
+	ShareCode   string       `json:"shareCode"`
+	GameVersion string       `json:"gameVersion"`
+	Government  string       `json:"government"`
+	Authority   jsonChoice   `json:"authority"`
+	Ethics      []jsonChoice `json:"ethics"`
+	Civics      []jsonChoice `json:"civics"`
+	CivicSlots  jsonSlots    `json:"civicSlots"`
+	Predicted   *jsonChoice  `json:"predictedCivic,omitempty"`
+	Origin      jsonChoice   `json:"origin"`
+	Homeplanet  jsonChoice   `json:"homeplanet"`
+	MainSpecies jsonSpecies  `json:"mainSpecies"`
+	SubSpecies  *jsonSpecies `json:"subSpecies,omitempty"`
+	Leaders     []jsonLeader `json:"leaders"`
+	Trace       []*traceStep `json:"trace,omitempty"`
+}
+
+type jsonChoice struct {
+	Name        string  `json:"name"`
+	Probability float64 `json:"probability"`
+}
+
+type jsonSlots struct {
+	Free   int `json:"free"`
+	Locked int `json:"locked"`
+}
+
+type jsonNames struct {
+	Singular  string `json:"singular"`
+	Plural    string `json:"plural"`
+	Adjective string `json:"adjective"`
+}
+
+type jsonLeader struct {
+	Class  string   `json:"class"`
+	Name   string   `json:"name"`
+	Traits []string `json:"traits"`
+}
+
+type jsonSpecies struct {
+	Role           string       `json:"role,omitempty"` // what a secondary species is to the empire
+	PopType        string       `json:"popType"`
+	Traits         []jsonChoice `json:"traits"`
+	PreferredClass string       `json:"preferredClass"`
+	Habitability   int          `json:"habitability"` // on the homeworld, in percent
+	PointsLeft     int          `json:"pointsLeft"`
+	LifespanMalus  int          `json:"lifespanMalus,omitempty"` // leader lifespan in years lost to overtuned traits
+}
+
+func (e Empire) MarshalJSON() ([]byte, error) {
+	n := generateNames(e)
+	res := jsonEmpire{
+		Player:      e.player,
+		Name:        n.empire,
+		Species:     jsonNames{Singular: n.species, Plural: n.plural, Adjective: n.adjective},
+		Homeworld:   n.homeworld,
+		NameSeed:    e.nameSeed,
+		ShareCode:   shareCode(e),
+		GameVersion: e.gameVersion(),
+		Government:  governmentName(e),
+		Authority:   jsonChoice{Name: e.authority, Probability: e.odds.authority},
+		Ethics:      []jsonChoice{},
+		Civics:      []jsonChoice{},
+		Origin:      jsonChoice{Name: e.origin.name, Probability: e.odds.origin},
+		Homeplanet:  jsonChoice{Name: e.homeplanet, Probability: e.odds.homeplanet},
+		MainSpecies: speciesJSON(e, e.mainSpecies, e.odds.mainTraits),
+	}
+	for i, ethic := range e.ethics {
+		res.Ethics = append(res.Ethics, jsonChoice{Name: ethic.name, Probability: e.odds.ethics[i]})
+	}
+	for i, civic := range e.civics {
+		res.Civics = append(res.Civics, jsonChoice{Name: civic.name, Probability: e.odds.civics[i]})
+	}
+	res.CivicSlots.Free, res.CivicSlots.Locked = civicSlots(e)
+	if e.predicted.name != "" {
+		res.Predicted = &jsonChoice{Name: e.predicted.name, Probability: e.odds.predicted}
+	}
+	if len(e.subSpecies.traits) > 0 {
+		sub := speciesJSON(e, e.subSpecies, e.odds.subTraits)
+		sub.Role = subSpeciesName(e)
+		res.SubSpecies = &sub
+	}
+	for _, leader := range generateLeaders(e) {
+		res.Leaders = append(res.Leaders, jsonLeader{Class: leader.class, Name: leader.name, Traits: leaderTraitNames(leader.traits)})
+	}
+	if e.trace != nil {
+		res.Trace = e.trace.steps
+	}
+	return json.Marshal(res)
+}
+
+// UnmarshalJSON restores an empire sent by the lobby server, looking its components up in the catalogue
+// of the version it was made with. The empire keeps that version, nothing else changes.
+func (e *Empire) UnmarshalJSON(data []byte) error {
+	res := jsonEmpire{GameVersion: unversionedCatalogue}
+	if err := json.Unmarshal(data, &res); err != nil {
+		return err
+	}
+	c, err := catalogueFor(res.GameVersion)
+	if err != nil {
+		return err
+	}
+	*e = Empire{
+		version:    c.version,
+		player:     res.Player,
+		authority:  res.Authority.Name,
+		homeplanet: res.Homeplanet.Name,
+		nameSeed:   res.NameSeed,
+		odds:       odds{authority: res.Authority.Probability, origin: res.Origin.Probability, homeplanet: res.Homeplanet.Probability},
+	}
+	for _, ethic := range res.Ethics {
+		e.ethics = append(e.ethics, ethicByName(ethic.Name))
+		e.odds.ethics = append(e.odds.ethics, ethic.Probability)
+	}
+	for _, civic := range res.Civics {
+		e.civics = append(e.civics, c.civic(civic.Name))
+		e.odds.civics = append(e.odds.civics, civic.Probability)
+	}
+	e.origin = c.origin(res.Origin.Name)
+	if res.Predicted != nil {
+		e.predicted = c.civic(res.Predicted.Name)
+		e.odds.predicted = res.Predicted.Probability
+	}
+	e.mainSpecies, e.odds.mainTraits = speciesFromJSON(c, res.MainSpecies)
+	if res.SubSpecies != nil {
+		e.subSpecies, e.odds.subTraits = speciesFromJSON(c, *res.SubSpecies)
+	}
+	if res.Trace != nil {
+		e.trace = &trace{steps: res.Trace}
+	}
+	*e = restoreBudgets(*e)
+	return nil
+}
+
+func speciesFromJSON(c *catalogue, s jsonSpecies) (Species, map[string]float64) {
+	species := Species{popType: s.PopType, initialTraitPoints: initialTraitPoints(s.PopType), preferredClass: s.PreferredClass, version: c.version}
+	chances := map[string]float64{}
+	for _, trait := range s.Traits {
+		species.traits = append(species.traits, c.trait(trait.Name))
+		chances[trait.Name] = trait.Probability
+	}
+	return species, chances
+}
+
+func speciesJSON(e Empire, s Species, chances map[string]float64) jsonSpecies {
+	res := jsonSpecies{PopType: s.popType, Traits: []jsonChoice{}, PreferredClass: s.preferredClass, Habitability: habitability(s, e.homeplanet), PointsLeft: s.pointsLeft(), LifespanMalus: lifespanMalus(s)}
+	for _, trait := range s.traits {
+		res.Traits = append(res.Traits, jsonChoice{Name: trait.name, Probability: chances[trait.name]})
+	}
+	return res
+}
+
+func runGenerate(args []string) {
+	flags := flag.NewFlagSet("generate", flag.ExitOnError)
+	count := flags.Int("n", 3, "number of empires to generate")
+	format := flags.String("format", "text", "output format: text or json")
+	seedFlag := flags.Int64("seed", seed, "seed for the random generator")
+	traceFlag := flags.Bool("trace", false, "record why options were excluded at every step")
+	players := flags.String("players", "", "comma separated player names, one empire each")
+	uniqueFlag := flags.String("unique", "", "comma separated list of origin, authority and civic that no two empires may share")
+	diverse := flags.Bool("diverse", false, "pick empires that play as differently as possible")
+	leftover := flags.Bool("leftover", false, "allow species that leave trait points unspent")
+	orderFlag := flags.String("order", "", "comma separated generation steps to run first, out of ethics, authority, civics and origin, or random")
+	predict := flags.Bool("predict-civic", false, "also draw the civic taken when the first locked civic slot opens")
+	fanatic := flags.Float64("fanatic", defaultFanaticChance, "chance between 0 and 1 that a drawn ethic becomes fanatic")
+	version := flags.String("game-version", latestVersion, "catalogue version to generate for: "+strings.Join(versionNames(), ", ")+" or "+latestVersion)
+	flags.Parse(args)
+	r.Seed(*seedFlag)
+	unique, err := parseUniqueness(*uniqueFlag)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(1)
+	}
+	order, shuffle, err := parseOrder(*orderFlag)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(1)
+	}
+	if *fanatic < 0 || *fanatic > 1 {
+		fmt.Fprintln(os.Stderr, "-fanatic has to be between 0 and 1")
+		os.Exit(1)
+	}
+	empires, err := generateBatch(playerList(*players, *count), batchOptions{unique: unique, diverse: *diverse, traced: *traceFlag, leftover: *leftover, fanatic: *fanatic, predict: *predict, order: order, shuffle: shuffle, version: *version})
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(1)
+	}
+	switch *format {
+	case "text":
+		fmt.Println("Seed is " + fmt.Sprint(*seedFlag))
+		for _, empire := range empires {
+			if empire.player != "" {
+				fmt.Println(empire.player)
+			}
+			fmt.Println(empire)
+			if empire.trace != nil {
+				fmt.Print(empire.trace)
+			}
+			fmt.Println()
+		}
+	case "json":
+		for i := range empires {
+			empires[i] = estimateTraitOdds(empires[i])
+		}
+		enc := json.NewEncoder(os.Stdout)
+		enc.SetIndent("", "  ")
+		if err := enc.Encode(empires); err != nil {
+			fmt.Fprintln(os.Stderr, err)
+			os.Exit(1)
+		}
+	default:
+		fmt.Fprintf(os.Stderr, "unknown format %q\n", *format)
+		os.Exit(1)
+	}
+}
+
+// runDecode prints the empires of the given share codes.
+func runDecode(args []string) {
+	flags := flag.NewFlagSet("decode", flag.ExitOnError)
+	format := flags.String("format", "text", "output format: text or json")
+	flags.Parse(args)
+	empires := []Empire{}
+	for _, code := range flags.Args() {
+		empire, err := decodeShareCode(code)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "%s: %v\n", code, err)
+			os.Exit(1)
+		}
+		empires = append(empires, empire)
+	}
+	switch *format {
+	case "text":
+		for _, empire := range empires {
+			fmt.Println(empire)
+			fmt.Println()
+		}
+	case "json":
+		enc := json.NewEncoder(os.Stdout)
+		enc.SetIndent("", "  ")
+		if err := enc.Encode(empires); err != nil {
+			fmt.Fprintln(os.Stderr, err)
+			os.Exit(1)
+		}
+	default:
+		fmt.Fprintf(os.Stderr, "unknown format %q\n", *format)
+		os.Exit(1)
+	}
+}
@@ -0,0 +1,204 @@
+package main
+
+import (
+	"fmt"
+	"strings"
+)
+
+// PlanetClass is a class of planet a species can live on. Standard classes belong to a climate,
+// special classes have none and only become the homeworld when an origin forces them. Species
+// with the adapted trait live on a special class as well as on their preferred class.
+type PlanetClass struct {
+	name         string
+	climate      string // dry, wet or cold, empty for special classes
+	habitability int    // habitability of special classes for species that are not adapted
+	adapted      string
+}
+
+var allPlanetClasses = []PlanetClass{
+	{name: "Desert", climate: "dry"},
+	{name: "Arid", climate: "dry"},
+	{name: "Savanna", climate: "dry"},
+	{name: "Ocean", climate: "wet"},
+	{name: "Continental", climate: "wet"},
+	{name: "Tropical", climate: "wet"},
+	{name: "Arctic", climate: "cold"},
+	{name: "Alpine", climate: "cold"},
+	{name: "Tundra", climate: "cold"},
+	{name: "Habitat", habitability: 70, adapted: "Void Dweller"},
+	{name: "Ring World", habitability: 100},
+	{name: "Gaia", habitability: 100},
+	{name: "Relic", habitability: 80},
+	{name: "Tomb", habitability: 10, adapted: "Survivor"},
+}
+
+// planetRule changes the homeworld candidates when it applies to the empire and species. force
+// replaces the candidates, forbid removes them and weight multiplies their chance by factor.
+// Machine species have no rules, so they are equally likely to start on any standard class.
+type planetRule struct {
+	source  string // the origin, civic, pop type or trait the rule comes from
+	when    Predicate
+	species speciesPredicate
+	force   []string
+	forbid  []string
+	weight  []string
+	factor  int
+}
+
+var planetRules = []planetRule{
+	{source: "origin Void Dwellers", when: includeOrigin("Void Dwellers"), species: sAlways, force: []string{"Habitat"}},
+	{source: "origin Shattered Ring", when: includeOrigin("Shattered Ring"), species: sAlways, force: []string{"Ring World"}},
+	{source: "origin Life-Seeded", when: includeOrigin("Life-Seeded"), species: sAlways, force: []string{"Gaia"}},
+	{source: "origin Remnants", when: includeOrigin("Remnants"), species: sAlways, force: []string{"Relic"}},
+	{source: "origin Post-Apocalyptic", when: includeOrigin("Post-Apocalyptic"), species: sAlways, force: []string{"Tomb"}},
+	{source: "origin Ocean Paradise", when: includeOrigin("Ocean Paradise"), species: sAlways, force: []string{"Ocean"}},
+	{source: "civic Anglers", when: includeCivic("Anglers"), species: sAlways, weight: []string{"Ocean"}, factor: 4},
+	{source: "pop type Lithoid", when: always, species: includeType("Lithoid"), weight: []string{"Desert", "Arid", "Savanna"}, factor: 3},
+	{source: "trait Aquatic", when: always, species: includeTrait("Aquatic"), forbid: []string{"Desert", "Arid"}, weight: []string{"Ocean"}, factor: 3},
+	{source: "trait Phototropic", when: always, species: includeTrait("Phototropic"), forbid: []string{"Arctic", "Tundra"}, weight: []string{"Tropical", "Savanna"}, factor: 2},
+}
+
+// planetWeight is a candidate class with its relative chance.
+type planetWeight struct {
+	class  PlanetClass
+	weight int
+}
+
+// chooseHomeplanet draws the homeworld class from the rules for the main species,
+// then the preferred class of every species.
+func chooseHomeplanet(empire Empire) Empire {
+	step := empire.trace.begin("chooseHomeplanet", "")
+	candidates := planetCandidates(empire, empire.mainSpecies, true, step)
+	class, chance := drawPlanet(candidates)
+	empire.homeplanet = class.name
+	empire.odds.homeplanet = chance
+	if step != nil {
+		for _, candidate := range candidates {
+			step.Candidates = append(step.Candidates, fmt.Sprintf("%s ×%d", candidate.class.name, candidate.weight))
+		}
+		step.Drawn = empire.homeplanet
+	}
+	if class.climate != "" {
+		empire.mainSpecies.preferredClass = class.name
+	} else {
+		empire.mainSpecies.preferredClass, _ = drawPreferred(empire, empire.mainSpecies)
+	}
+	if template := subSpeciesTemplate(speciesRulesOf(empire)); template != nil && template.samePopType {
+		empire.subSpecies.preferredClass = empire.mainSpecies.preferredClass
+	} else if empire.subSpecies.popType != "" {
+		empire.subSpecies.preferredClass, _ = drawPreferred(empire, empire.subSpecies)
+	}
+	return empire
+}
+
+// drawPreferred draws the preferred standard class of a species, ignoring origins that force the homeworld.
+func drawPreferred(empire Empire, species Species) (string, float64) {
+	class, chance := drawPlanet(planetCandidates(empire, species, false, nil))
+	return class.name, chance
+}
+
+// planetCandidates applies every rule that matches the empire and species to the standard classes.
+// Forcing rules only apply to the homeworld, and forced classes cannot be forbidden again.
+func planetCandidates(empire Empire, species Species, homeworld bool, step *traceStep) []planetWeight {
+	forced := []string{}
+	sources := []string{}
+	for _, rule := range planetRules {
+		if homeworld && len(rule.force) > 0 && rule.when.test(empire) && rule.species.test(species) {
+			forced = append(forced, rule.force...)
+			sources = append(sources, rule.source)
+		}
+	}
+	res := []planetWeight{}
+	for _, class := range allPlanetClasses {
+		switch {
+		case len(forced) > 0 && !contains(forced, class.name):
+			step.exclude(class.name, "replaced by "+strings.Join(forced, " or ")+" from "+strings.Join(sources, " and "))
+		case len(forced) == 0 && class.climate == "":
+			step.exclude(class.name, "only available to origins that force it")
+		default:
+			res = append(res, planetWeight{class: class, weight: 1})
+		}
+	}
+	for _, rule := range planetRules {
+		if !rule.when.test(empire) || !rule.species.test(species) {
+			continue
+		}
+		kept := []planetWeight{}
+		for _, candidate := range res {
+			if len(forced) == 0 && contains(rule.forbid, candidate.class.name) {
+				step.exclude(candidate.class.name, "forbidden by "+rule.source)
+				continue
+			}
+			if contains(rule.weight, candidate.class.name) {
+				candidate.weight *= rule.factor
+			}
+			kept = append(kept, candidate)
+		}
+		res = kept
+	}
+	return res
+}
+
+func drawPlanet(candidates []planetWeight) (PlanetClass, float64) {
+	total := 0
+	for _, candidate := range candidates {
+		total += candidate.weight
+	}
+	roll := r.Intn(total)
+	for _, candidate := range candidates {
+		if roll < candidate.weight {
+			return candidate.class, float64(candidate.weight) / float64(total)
+		}
+		roll -= candidate.weight
+	}
+	return PlanetClass{}, 0
+}
+
+func planetClassByName(name string) PlanetClass {
+	for _, class := range allPlanetClasses {
+		if class.name == name {
+			return class
+		}
+	}
+	return PlanetClass{name: name}
+}
+
+func planetClassExists(name string) bool {
+	for _, class := range allPlanetClasses {
+		if class.name == name {
+			return true
+		}
+	}
+	return false
+}
+
+// habitability is the base habitability in percent of the species on a planet class. Machines and robots live anywhere.
+// On standard classes the preferred class gives 80, the same climate 60 and any other climate 20.
+func habitability(species Species, planet string) int {
+	class := planetClassByName(planet)
+	switch {
+	case archetypeOf(species.popType).name == "Machine", archetypeOf(species.popType).name == "Robot":
+		return 100
+	case class.climate == "":
+		for _, trait := range species.traits {
+			if class.adapted != "" && trait.name == class.adapted {
+				return 100
+			}
+		}
+		return class.habitability
+	case class.name == species.preferredClass:
+		return 80
+	case class.climate == planetClassByName(species.preferredClass).climate:
+		return 60
+	}
+	return 20
+}
+
+// habitabilityText describes how well the species lives on the homeworld of the empire.
+func habitabilityText(empire Empire, species Species) string {
+	res := fmt.Sprintf("%d%% on %s", habitability(species, empire.homeplanet), empire.homeplanet)
+	if species.preferredClass != "" && species.preferredClass != empire.homeplanet {
+		res += ", prefers " + species.preferredClass
+	}
+	return res
+}
@@ -0,0 +1,249 @@
+package main
+
+import (
+	"encoding/json"
+	"flag"
+	"fmt"
+	"net/http"
+	"os"
+	"strings"
+	"sync"
+
+	"github.com/gorilla/websocket"
+	"github.com/maxence-charriere/go-app/v9/pkg/app"
+)
+
+// server keeps the drafts of the JSON API and the lobbies in memory. The generator shares the
+// package level random source, so every request holds the lock while it rolls.
+type server struct {
+	mu      sync.Mutex
+	drafts  map[string]*draft
+	lobbies map[string]*lobby
+	web     string
+}
+
+var upgrader = websocket.Upgrader{
+	// the web app may be served from anywhere, e.g. GitHub pages, and lobbies hold no secrets
+	CheckOrigin: func(req *http.Request) bool { return true },
+}
+
+func runServe(args []string) {
+	flags := flag.NewFlagSet("serve", flag.ExitOnError)
+	addr := flags.String("addr", "localhost:8080", "address to listen on")
+	web := flags.String("web", "docs", "directory with the built web app, served on the same address")
+	flags.Parse(args)
+	s := &server{drafts: map[string]*draft{}, lobbies: map[string]*lobby{}, web: *web}
+	fmt.Println("Listening on " + *addr)
+	if err := http.ListenAndServe(*addr, s.routes()); err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(1)
+	}
+}
+
+func (s *server) routes() *http.ServeMux {
+	mux := http.NewServeMux()
+	mux.HandleFunc("/api/drafts", s.createDraft)
+	mux.HandleFunc("/api/drafts/", s.draftAction)
+	mux.HandleFunc("/ws", s.joinLobby)
+	mux.Handle("/", appHandler(app.LocalDir(s.web)))
+	return mux
+}
+
+type draftRequest struct {
+	Players       []string `json:"players"`
+	HandSize      int      `json:"handSize"`
+	BansPerPlayer int      `json:"bansPerPlayer"`
+	GameVersion   string   `json:"gameVersion"` // the latest one when empty
+	CardKind      string   `json:"cardKind"`    // empire, origin or civic, empire when empty
+}
+
+type actionRequest struct {
+	Player string `json:"player"`
+	Kind   string `json:"kind"`
+	Name   string `json:"name"`
+	Card   int    `json:"card"`
+}
+
+// createDraft handles POST /api/drafts.
+func (s *server) createDraft(w http.ResponseWriter, req *http.Request) {
+	if req.Method != http.MethodPost {
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	body := draftRequest{HandSize: 3, BansPerPlayer: 1}
+	if err := json.NewDecoder(req.Body).Decode(&body); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	d, err := newDraft(body.Players, body.HandSize, body.BansPerPlayer, body.GameVersion, body.CardKind)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	d.ID = s.newCode()
+	s.drafts[d.ID] = d
+	writeJSON(w, d)
+}
+
+// draftAction handles GET /api/drafts/{id} and POST /api/drafts/{id}/ban and /api/drafts/{id}/pick.
+func (s *server) draftAction(w http.ResponseWriter, req *http.Request) {
+	parts := strings.Split(strings.TrimPrefix(req.URL.Path, "/api/drafts/"), "/")
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	d, ok := s.drafts[parts[0]]
+	if !ok {
+		http.Error(w, "unknown draft", http.StatusNotFound)
+		return
+	}
+	if len(parts) == 1 && req.Method == http.MethodGet {
+		writeJSON(w, d)
+		return
+	}
+	if len(parts) != 2 || req.Method != http.MethodPost {
+		http.Error(w, "not found", http.StatusNotFound)
+		return
+	}
+	body := actionRequest{}
+	if err := json.NewDecoder(req.Body).Decode(&body); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	var err error
+	switch parts[1] {
+	case "ban":
+		err = d.ban(body.Player, body.Kind, body.Name)
+	case "pick":
+		err = d.pick(body.Player, body.Card)
+	default:
+		http.Error(w, "not found", http.StatusNotFound)
+		return
+	}
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusConflict)
+		return
+	}
+	writeJSON(w, d)
+}
+
+// joinLobby handles /ws?name=...&code=... and keeps the socket open until the member leaves.
+// Without a code it creates a new lobby with the member as host. The member takes its place in the
+// lobby under the lock, before the upgrade, so the lobby cannot disappear in between.
+func (s *server) joinLobby(w http.ResponseWriter, req *http.Request) {
+	name := req.URL.Query().Get("name")
+	code := strings.ToUpper(req.URL.Query().Get("code"))
+	if name == "" {
+		http.Error(w, "a name is required", http.StatusBadRequest)
+		return
+	}
+	m := &member{name: name, send: make(chan []byte, memberQueue)}
+	s.mu.Lock()
+	l, ok := s.lobbies[code]
+	switch {
+	case code == "":
+		l = &lobby{Code: s.newCode(), Settings: lobbySettings{Count: 3, FanaticChance: defaultFanaticChance * 100}}
+		l.reroll()
+		s.lobbies[l.Code] = l
+	case !ok:
+		s.mu.Unlock()
+		http.Error(w, "unknown lobby", http.StatusNotFound)
+		return
+	case l.member(name) != nil:
+		s.mu.Unlock()
+		http.Error(w, "the name "+name+" is taken in this lobby", http.StatusConflict)
+		return
+	}
+	l.join(m)
+	s.mu.Unlock()
+
+	conn, err := upgrader.Upgrade(w, req, nil)
+	if err != nil {
+		s.leave(l, m)
+		return
+	}
+	defer conn.Close()
+	s.mu.Lock()
+	m.conn = conn
+	go m.write()
+	s.broadcast(l)
+	s.mu.Unlock()
+
+	for {
+		msg := lobbyMessage{}
+		if err := conn.ReadJSON(&msg); err != nil {
+			break
+		}
+		s.mu.Lock()
+		switch {
+		case msg.Type == "configure" && msg.Settings != nil && l.host == m:
+			// invalid settings are reported but not applied, so rerolls keep working
+			if err := msg.Settings.validate(); err != nil {
+				l.Error = err.Error()
+				break
+			}
+			l.Settings = *msg.Settings
+			l.reroll()
+		case msg.Type == "reroll":
+			l.reroll()
+		}
+		s.broadcast(l)
+		s.mu.Unlock()
+	}
+	s.leave(l, m)
+}
+
+// leave removes the member from the lobby, and the lobby from the server once it is empty.
+func (s *server) leave(l *lobby, m *member) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	l.leave(m)
+	close(m.send)
+	if len(l.members) == 0 {
+		delete(s.lobbies, l.Code)
+		return
+	}
+	s.broadcast(l)
+}
+
+// broadcast queues the lobby for every member that is connected. Callers hold the lock; the writes
+// happen in the writer of each member, so a stalled client does not hold up the server.
+func (s *server) broadcast(l *lobby) {
+	data, err := json.Marshal(l)
+	if err != nil {
+		return
+	}
+	for _, m := range l.members {
+		if m.conn == nil {
+			continue
+		}
+		select {
+		case m.send <- data:
+		default: // too far behind, closing makes its reader leave the lobby
+			m.conn.Close()
+		}
+	}
+}
+
+// newCode returns a short code that is not in use yet, by drafts or lobbies. Callers hold the lock.
+func (s *server) newCode() string {
+	const letters = "ABCDEFGHJKLMNPQRSTUVWXYZ"
+	for {
+		code := ""
+		for i := 0; i < 6; i++ {
+			code += string(letters[r.Intn(len(letters))])
+		}
+		_, draftExists := s.drafts[code]
+		_, lobbyExists := s.lobbies[code]
+		if !draftExists && !lobbyExists {
+			return code
+		}
+	}
+}
+
+func writeJSON(w http.ResponseWriter, v interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	if err := json.NewEncoder(w).Encode(v); err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+	}
+}
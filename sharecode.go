@@ -0,0 +1,173 @@
+package main
+
+import (
+	"bytes"
+	"compress/flate"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+)
+
+// shareCodeVersion is the first field of every share code, so the layout can change without breaking old codes.
+const shareCodeVersion = 4
+
+// shareCodeFields is the number of fields per share code version. Version 2 added the preferred planet classes,
+// version 3 the predicted civic, version 4 the catalogue version. Older codes were made with unversionedCatalogue.
+var shareCodeFields = map[string]int{"1": 11, "2": 13, "3": 14, "4": 15}
+
+// Share codes store names instead of catalogue positions, so reordering the catalogue keeps them valid.
+// The fields are joined with fieldSeparator, lists inside a field with listSeparator.
+const (
+	fieldSeparator = "|"
+	listSeparator  = ","
+)
+
+// shareCode encodes everything needed to rebuild the empire, including the seed of its names.
+// Odds and traces are not part of the code, they describe how the empire was rolled.
+func shareCode(e Empire) string {
+	fields := []string{
+		strconv.Itoa(shareCodeVersion),
+		e.authority,
+		strings.Join(ethicNames(e.ethics), listSeparator),
+		strings.Join(civicNames(e.civics), listSeparator),
+		e.origin.name,
+		e.homeplanet,
+		e.mainSpecies.popType,
+		strings.Join(traitNames(e.mainSpecies.traits), listSeparator),
+		e.subSpecies.popType,
+		strings.Join(traitNames(e.subSpecies.traits), listSeparator),
+		strconv.FormatInt(e.nameSeed, 36),
+		e.mainSpecies.preferredClass,
+		e.subSpecies.preferredClass,
+		e.predicted.name,
+		e.gameVersion(),
+	}
+	buf := bytes.Buffer{}
+	w, _ := flate.NewWriter(&buf, flate.BestCompression)
+	w.Write([]byte(strings.Join(fields, fieldSeparator)))
+	w.Close()
+	return base64.RawURLEncoding.EncodeToString(buf.Bytes())
+}
+
+// decodeShareCode rebuilds an empire from a share code, rejecting names the catalogue does not know.
+// It uses the catalogue of the version the code was made with, so codes stay valid across patches.
+func decodeShareCode(code string) (Empire, error) {
+	compressed, err := base64.RawURLEncoding.DecodeString(strings.TrimSpace(code))
+	if err != nil {
+		return Empire{}, errors.New("share code is not valid base64")
+	}
+	raw, err := io.ReadAll(flate.NewReader(bytes.NewReader(compressed)))
+	if err != nil {
+		return Empire{}, errors.New("share code is corrupted")
+	}
+	fields := strings.Split(string(raw), fieldSeparator)
+	expected, ok := shareCodeFields[fields[0]]
+	if !ok {
+		return Empire{}, fmt.Errorf("unknown share code version %q", fields[0])
+	}
+	if len(fields) != expected {
+		return Empire{}, fmt.Errorf("share code has %d fields instead of %d", len(fields), expected)
+	}
+	version := unversionedCatalogue
+	if len(fields) > 14 {
+		version = fields[14]
+	}
+	c, err := catalogueFor(version)
+	if err != nil {
+		return Empire{}, err
+	}
+	empire := Empire{authority: fields[1], homeplanet: fields[5], version: c.version}
+	if !c.has("authority", empire.authority) {
+		return Empire{}, fmt.Errorf("unknown authority %q", empire.authority)
+	}
+	for _, name := range splitList(fields[2]) {
+		if !contains(ethicOptions(), name) {
+			return Empire{}, fmt.Errorf("unknown ethic %q", name)
+		}
+		empire.ethics = append(empire.ethics, ethicByName(name))
+	}
+	for _, name := range splitList(fields[3]) {
+		if !c.has("civic", name) {
+			return Empire{}, fmt.Errorf("unknown civic %q", name)
+		}
+		empire.civics = append(empire.civics, c.civic(name))
+	}
+	if !c.has("origin", fields[4]) {
+		return Empire{}, fmt.Errorf("unknown origin %q", fields[4])
+	}
+	empire.origin = c.origin(fields[4])
+	if empire.mainSpecies, err = speciesFromCode(c, fields[6], fields[7]); err != nil {
+		return Empire{}, err
+	}
+	if fields[8] != "" {
+		if empire.subSpecies, err = speciesFromCode(c, fields[8], fields[9]); err != nil {
+			return Empire{}, err
+		}
+	}
+	if empire.nameSeed, err = strconv.ParseInt(fields[10], 36, 64); err != nil {
+		return Empire{}, fmt.Errorf("invalid name seed %q", fields[10])
+	}
+	if len(fields) > 11 {
+		empire.mainSpecies.preferredClass = fields[11]
+		empire.subSpecies.preferredClass = fields[12]
+	} else if planetClassByName(empire.homeplanet).climate != "" {
+		// version 1 codes predate preferred classes, the homeworld was always a standard class
+		empire.mainSpecies.preferredClass = empire.homeplanet
+	}
+	if len(fields) > 13 && fields[13] != "" {
+		if !c.has("civic", fields[13]) {
+			return Empire{}, fmt.Errorf("unknown civic %q", fields[13])
+		}
+		empire.predicted = c.civic(fields[13])
+	}
+	for _, class := range []string{empire.homeplanet, empire.mainSpecies.preferredClass, empire.subSpecies.preferredClass} {
+		if class != "" && !planetClassExists(class) {
+			return Empire{}, fmt.Errorf("unknown planet class %q", class)
+		}
+	}
+	empire = restoreBudgets(empire)
+	empire.odds.ethics = make([]float64, len(empire.ethics))
+	empire.odds.civics = make([]float64, len(empire.civics))
+	return empire, nil
+}
+
+func speciesFromCode(c *catalogue, popType string, traits string) (Species, error) {
+	if !c.hasPopType(popType) {
+		return Species{}, fmt.Errorf("unknown pop type %q", popType)
+	}
+	species := Species{popType: popType, initialTraitPoints: initialTraitPoints(popType), version: c.version}
+	for _, name := range splitList(traits) {
+		trait := c.trait(name)
+		if _, ok := originTraits[name]; !ok && trait.isAllowed.kind == "never" {
+			return Species{}, fmt.Errorf("unknown trait %q", name)
+		}
+		species.traits = append(species.traits, trait)
+	}
+	return species, nil
+}
+
+func splitList(field string) []string {
+	if field == "" {
+		return nil
+	}
+	return strings.Split(field, listSeparator)
+}
+
+func civicNames(civics []Civic) []string {
+	res := []string{}
+	for _, civic := range civics {
+		res = append(res, civic.name)
+	}
+	return res
+}
+
+func traitNames(traits []Trait) []string {
+	res := []string{}
+	for _, trait := range traits {
+		res = append(res, trait.name)
+	}
+	return res
+}
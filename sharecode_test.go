@@ -0,0 +1,136 @@
+package main
+
+import (
+	"bytes"
+	"compress/flate"
+	"encoding/base64"
+	"io"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+// codeFields unpacks a share code into its fields, see shareCode.
+func codeFields(t *testing.T, code string) []string {
+	compressed, err := base64.RawURLEncoding.DecodeString(code)
+	if err != nil {
+		t.Fatal(err)
+	}
+	raw, err := io.ReadAll(flate.NewReader(bytes.NewReader(compressed)))
+	if err != nil {
+		t.Fatal(err)
+	}
+	return strings.Split(string(raw), fieldSeparator)
+}
+
+// packFields is shareCode for hand made fields, such as those of older share code versions.
+func packFields(fields []string) string {
+	buf := bytes.Buffer{}
+	w, _ := flate.NewWriter(&buf, flate.BestCompression)
+	w.Write([]byte(strings.Join(fields, fieldSeparator)))
+	w.Close()
+	return base64.RawURLEncoding.EncodeToString(buf.Bytes())
+}
+
+func TestShareCodeVersions(t *testing.T) {
+	r.Seed(1)
+	for _, version := range versionNames() {
+		empires, err := generateBatch([]string{"", "", ""}, batchOptions{fanatic: defaultFanaticChance, predict: true, version: version})
+		if err != nil {
+			t.Fatal(err)
+		}
+		for _, empire := range empires {
+			code := shareCode(empire)
+			fields := codeFields(t, code)
+			tests := []struct {
+				codeVersion string
+				fields      int
+				version     string // the catalogue version the code decodes against
+			}{
+				{"1", 11, unversionedCatalogue},
+				{"2", 13, unversionedCatalogue},
+				{"3", 14, unversionedCatalogue},
+				{"4", 15, version},
+			}
+			for _, test := range tests {
+				old := append([]string{test.codeVersion}, fields[1:test.fields]...)
+				decoded, err := decodeShareCode(packFields(old))
+				if err != nil {
+					t.Errorf("version %s code of %s: %v", test.codeVersion, code, err)
+					continue
+				}
+				if decoded.version != test.version {
+					t.Errorf("version %s code of %s follows %s", test.codeVersion, code, decoded.version)
+				}
+				if decoded.authority != empire.authority || decoded.origin.name != empire.origin.name || decoded.homeplanet != empire.homeplanet || decoded.nameSeed != empire.nameSeed {
+					t.Errorf("version %s code of %s decodes to another empire", test.codeVersion, code)
+				}
+				if !reflect.DeepEqual(civicNames(decoded.civics), civicNames(empire.civics)) || !reflect.DeepEqual(ethicNames(decoded.ethics), ethicNames(empire.ethics)) {
+					t.Errorf("version %s code of %s decodes to other civics or ethics", test.codeVersion, code)
+				}
+				for _, pair := range [][2]Species{{decoded.mainSpecies, empire.mainSpecies}, {decoded.subSpecies, empire.subSpecies}} {
+					if pair[0].popType != pair[1].popType || !reflect.DeepEqual(traitNames(pair[0].traits), traitNames(pair[1].traits)) || pair[0].pointsLeft() != pair[1].pointsLeft() {
+						t.Errorf("version %s code of %s decodes to other species", test.codeVersion, code)
+					}
+				}
+				wantPreferred := empire.mainSpecies.preferredClass
+				if test.fields < 13 {
+					wantPreferred = ""
+					if planetClassByName(empire.homeplanet).climate != "" {
+						wantPreferred = empire.homeplanet
+					}
+				}
+				if decoded.mainSpecies.preferredClass != wantPreferred {
+					t.Errorf("version %s code of %s prefers %q, want %q", test.codeVersion, code, decoded.mainSpecies.preferredClass, wantPreferred)
+				}
+				wantPredicted := empire.predicted.name
+				if test.fields < 14 {
+					wantPredicted = ""
+				}
+				if decoded.predicted.name != wantPredicted {
+					t.Errorf("version %s code of %s predicts %q, want %q", test.codeVersion, code, decoded.predicted.name, wantPredicted)
+				}
+				if test.codeVersion == "4" && shareCode(decoded) != code {
+					t.Errorf("code %s comes back as %s", code, shareCode(decoded))
+				}
+			}
+		}
+	}
+}
+
+func TestDecodeShareCodeErrors(t *testing.T) {
+	empires, err := generateBatch([]string{""}, batchOptions{fanatic: defaultFanaticChance})
+	if err != nil {
+		t.Fatal(err)
+	}
+	fields := codeFields(t, shareCode(empires[0]))
+	with := func(changes map[int]string) string {
+		changed := append([]string{}, fields...)
+		for i, value := range changes {
+			changed[i] = value
+		}
+		return packFields(changed)
+	}
+	tests := []struct {
+		name string
+		code string
+		want string
+	}{
+		{"not base64", "!!!", "not valid base64"},
+		{"not compressed", base64.RawURLEncoding.EncodeToString([]byte("plain")), "corrupted"},
+		{"unknown code version", with(map[int]string{0: "9"}), "unknown share code version"},
+		{"missing field", packFields(fields[:14]), "has 14 fields instead of 15"},
+		{"unknown authority", with(map[int]string{1: "Anarchy"}), "unknown authority"},
+		{"unknown civic", with(map[int]string{3: "Pirates"}), "unknown civic"},
+		{"unknown origin", with(map[int]string{4: "Nowhere"}), "unknown origin"},
+		{"unknown pop type", with(map[int]string{6: "Crystalline"}), "unknown pop type"},
+		{"unknown trait", with(map[int]string{7: "Telepathic"}), "unknown trait"},
+		{"unknown game version", with(map[int]string{14: "1.0"}), "unknown game version"},
+		{"pop type newer than the version", with(map[int]string{6: "Toxoid", 14: "3.5"}), "unknown pop type \"Toxoid\""},
+	}
+	for _, test := range tests {
+		if _, err := decodeShareCode(test.code); err == nil || !strings.Contains(err.Error(), test.want) {
+			t.Errorf("%s: got error %v, want %q", test.name, err, test.want)
+		}
+	}
+}
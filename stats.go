@@ -0,0 +1,245 @@
+package main
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"sort"
+	"strconv"
+)
+
+// report holds the frequency of every component over a batch of generated empires.
+type report struct {
+	Samples int     `json:"samples"`
+	Seed    int64   `json:"seed"`
+	Version string  `json:"gameVersion"`
+	Tables  []table `json:"tables"`
+	Pairs   []pair  `json:"pairs"`
+}
+
+type table struct {
+	Name string      `json:"name"`
+	Rows []frequency `json:"rows"`
+}
+
+type frequency struct {
+	Name      string  `json:"name"`
+	Count     int     `json:"count"`
+	Frequency float64 `json:"frequency"`
+}
+
+type pair struct {
+	First     string  `json:"first"`
+	Second    string  `json:"second"`
+	Count     int     `json:"count"`
+	Frequency float64 `json:"frequency"`
+}
+
+func runStats(args []string) {
+	flags := flag.NewFlagSet("stats", flag.ExitOnError)
+	samples := flags.Int("n", 1000000, "number of empires to generate")
+	format := flags.String("format", "text", "output format: text, json or csv")
+	top := flags.Int("top", 25, "number of co-occurring pairs to report")
+	seedFlag := flags.Int64("seed", seed, "seed for the random generator")
+	orderFlag := flags.String("order", "", "comma separated generation steps to run first, or random, see generate")
+	version := flags.String("game-version", latestVersion, "catalogue version to sample, see generate")
+	flags.Parse(args)
+	r.Seed(*seedFlag)
+	order, shuffle, err := parseOrder(*orderFlag)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(1)
+	}
+	c, err := catalogueFor(*version)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(1)
+	}
+	rep, err := collectStats(*samples, *top, Empire{fanaticChance: defaultFanaticChance, order: order, shuffleOrder: shuffle, version: c.version})
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(1)
+	}
+	rep.Seed = *seedFlag
+	rep.Version = c.version
+	switch *format {
+	case "text":
+		err = writeText(os.Stdout, rep)
+	case "json":
+		enc := json.NewEncoder(os.Stdout)
+		enc.SetIndent("", "  ")
+		err = enc.Encode(rep)
+	case "csv":
+		err = writeCSV(os.Stdout, rep)
+	default:
+		err = fmt.Errorf("unknown format %q", *format)
+	}
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(1)
+	}
+}
+
+// collectStats fills copies of base, which carries the generation options and the catalogue version.
+func collectStats(samples int, top int, base Empire) (report, error) {
+	// every known name starts at zero, so components that never appear still show up
+	counts := map[string]map[string]int{}
+	order := []string{"Authority", "Ethic", "Civic", "Origin", "Pop Type", "Trait"}
+	for _, category := range order {
+		counts[category] = map[string]int{}
+	}
+	c := base.catalogue()
+	for _, auth := range c.authorities {
+		counts["Authority"][auth.name] = 0
+	}
+	for _, ethic := range allEthics {
+		counts["Ethic"][ethic.name] = 0
+	}
+	for _, civic := range c.civics {
+		counts["Civic"][civic.name] = 0
+	}
+	for _, origin := range c.origins {
+		counts["Origin"][origin.name] = 0
+	}
+	for _, archetype := range c.archetypes {
+		for _, popType := range archetype.popTypes {
+			counts["Pop Type"][popType] = 0
+		}
+	}
+	for _, traits := range [][]Trait{c.traits, c.overtuned} {
+		for _, trait := range traits {
+			counts["Trait"][trait.name] = 0
+		}
+	}
+	for _, trait := range originTraits {
+		counts["Trait"][trait.name] = 0
+	}
+
+	pairs := map[[2]string]int{}
+	for i := 0; i < samples; i++ {
+		empire, err := fillEmpire(base)
+		if err != nil {
+			return report{}, err
+		}
+		counts["Authority"][empire.authority]++
+		for _, ethic := range empire.ethics {
+			counts["Ethic"][ethic.name]++
+		}
+		for _, civic := range empire.civics {
+			counts["Civic"][civic.name]++
+		}
+		counts["Origin"][empire.origin.name]++
+		for _, species := range []Species{empire.mainSpecies, empire.subSpecies} {
+			if species.popType == "" {
+				continue
+			}
+			counts["Pop Type"][species.popType]++
+			for _, trait := range species.traits {
+				counts["Trait"][trait.name]++
+			}
+		}
+		components := empireComponents(empire)
+		for a := 0; a < len(components); a++ {
+			for b := a + 1; b < len(components); b++ {
+				first, second := components[a], components[b]
+				if second < first {
+					first, second = second, first
+				}
+				pairs[[2]string{first, second}]++
+			}
+		}
+	}
+
+	rep := report{Samples: samples}
+	for _, category := range order {
+		t := table{Name: category}
+		for name, count := range counts[category] {
+			t.Rows = append(t.Rows, frequency{Name: name, Count: count, Frequency: ratio(count, samples)})
+		}
+		sort.Slice(t.Rows, func(i, j int) bool {
+			if t.Rows[i].Count != t.Rows[j].Count {
+				return t.Rows[i].Count > t.Rows[j].Count
+			}
+			return t.Rows[i].Name < t.Rows[j].Name
+		})
+		rep.Tables = append(rep.Tables, t)
+	}
+	for key, count := range pairs {
+		rep.Pairs = append(rep.Pairs, pair{First: key[0], Second: key[1], Count: count, Frequency: ratio(count, samples)})
+	}
+	sort.Slice(rep.Pairs, func(i, j int) bool {
+		if rep.Pairs[i].Count != rep.Pairs[j].Count {
+			return rep.Pairs[i].Count > rep.Pairs[j].Count
+		}
+		if rep.Pairs[i].First != rep.Pairs[j].First {
+			return rep.Pairs[i].First < rep.Pairs[j].First
+		}
+		return rep.Pairs[i].Second < rep.Pairs[j].Second
+	})
+	if len(rep.Pairs) > top {
+		rep.Pairs = rep.Pairs[:top]
+	}
+	return rep, nil
+}
+
+// empireComponents lists the empire level choices that are compared for co-occurrence.
+func empireComponents(empire Empire) []string {
+	res := []string{"Authority: " + empire.authority, "Origin: " + empire.origin.name}
+	for _, ethic := range empire.ethics {
+		res = append(res, "Ethic: "+ethic.name)
+	}
+	for _, civic := range empire.civics {
+		res = append(res, "Civic: "+civic.name)
+	}
+	return res
+}
+
+func ratio(count int, samples int) float64 {
+	if samples == 0 {
+		return 0
+	}
+	return float64(count) / float64(samples)
+}
+
+func writeText(w io.Writer, rep report) error {
+	if _, err := fmt.Fprintf(w, "Samples: %d\nSeed: %d\nGame version: %s\n", rep.Samples, rep.Seed, rep.Version); err != nil {
+		return err
+	}
+	for _, t := range rep.Tables {
+		if _, err := fmt.Fprintf(w, "\n%s\n", t.Name); err != nil {
+			return err
+		}
+		for _, row := range t.Rows {
+			if _, err := fmt.Fprintf(w, "  %-40s %10d %8.4f%%\n", row.Name, row.Count, row.Frequency*100); err != nil {
+				return err
+			}
+		}
+	}
+	if _, err := fmt.Fprintf(w, "\nTop pairs\n"); err != nil {
+		return err
+	}
+	for _, p := range rep.Pairs {
+		if _, err := fmt.Fprintf(w, "  %-70s %10d %8.4f%%\n", p.First+" + "+p.Second, p.Count, p.Frequency*100); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func writeCSV(w io.Writer, rep report) error {
+	out := csv.NewWriter(w)
+	out.Write([]string{"table", "name", "count", "frequency"})
+	for _, t := range rep.Tables {
+		for _, row := range t.Rows {
+			out.Write([]string{t.Name, row.Name, strconv.Itoa(row.Count), strconv.FormatFloat(row.Frequency, 'f', -1, 64)})
+		}
+	}
+	for _, p := range rep.Pairs {
+		out.Write([]string{"Pair", p.First + " + " + p.Second, strconv.Itoa(p.Count), strconv.FormatFloat(p.Frequency, 'f', -1, 64)})
+	}
+	out.Flush()
+	return out.Error()
+}
@@ -0,0 +1,91 @@
+package main
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/maxence-charriere/go-app/v9/pkg/app"
+)
+
+// trace records every step of the generation of one empire. A nil trace records nothing,
+// so the generator can call it unconditionally.
+type trace struct {
+	steps []*traceStep
+}
+
+type traceStep struct {
+	Step       string      `json:"step"`
+	Detail     string      `json:"detail,omitempty"`
+	Candidates []string    `json:"candidates"`
+	Excluded   []exclusion `json:"excluded"`
+	Drawn      string      `json:"drawn"`
+}
+
+type exclusion struct {
+	Name   string `json:"name"`
+	Reason string `json:"reason"`
+}
+
+func (t *trace) begin(step string, detail string) *traceStep {
+	if t == nil {
+		return nil
+	}
+	res := &traceStep{Step: step, Detail: detail, Candidates: []string{}, Excluded: []exclusion{}}
+	t.steps = append(t.steps, res)
+	return res
+}
+
+func (s *traceStep) exclude(name string, reason string) {
+	if s == nil {
+		return
+	}
+	s.Excluded = append(s.Excluded, exclusion{Name: name, Reason: reason})
+}
+
+func (s *traceStep) draw(candidates []string, drawn string) {
+	if s == nil {
+		return
+	}
+	s.Candidates = candidates
+	s.Drawn = drawn
+}
+
+func (s *traceStep) title() string {
+	if s.Detail == "" {
+		return s.Step
+	}
+	return s.Step + " (" + s.Detail + ")"
+}
+
+func (t *trace) String() string {
+	res := ""
+	for _, step := range t.steps {
+		res += step.title() + ": drew " + step.Drawn + "\n"
+		if len(step.Candidates) > 0 {
+			res += "  candidates: " + strings.Join(step.Candidates, ", ") + "\n"
+		}
+		for _, excluded := range step.Excluded {
+			res += fmt.Sprintf("  excluded %s: %s\n", excluded.Name, excluded.Reason)
+		}
+	}
+	return res
+}
+
+func renderTrace(t *trace) app.UI {
+	if t == nil {
+		return app.Text("")
+	}
+	return app.Details().Body(
+		app.Summary().Text("Generation trace"),
+		app.Range(t.steps).Slice(func(i int) app.UI {
+			step := t.steps[i]
+			return app.Details().Class("trace").Body(
+				app.Summary().Text(step.title()+": "+step.Drawn),
+				app.Span().Text("Candidates: "+strings.Join(step.Candidates, ", ")),
+				app.Ul().Body(app.Range(step.Excluded).Slice(func(j int) app.UI {
+					return app.Li().Text(step.Excluded[j].Name + ": " + step.Excluded[j].Reason)
+				})),
+			)
+		}),
+	)
+}
@@ -0,0 +1,217 @@
+package main
+
+import (
+	"fmt"
+	"strings"
+)
+
+// catalogueVersion is a game version the catalogue can follow. The catalogue in main.go is the latest
+// version, every older version lists what the game did not have yet, so a patch is described once,
+// by the version before it.
+type catalogueVersion struct {
+	name    string
+	without []string // authorities, civics, origins, pop types and traits that came with a later version
+}
+
+// catalogueVersions run from the latest to the oldest. A version also lacks everything the versions
+// above it lack.
+var catalogueVersions = []catalogueVersion{
+	{name: "3.12"},
+	{name: "3.11", without: []string{"Rapid Replicator"}}, // The Machine Age
+	{name: "3.5", without: append([]string{"Knights of the Toxic God", "Overtuned", "Mutagenic Spas", "Relentless Industrialists", "Scavengers", "Toxoid", "Noxious", "Inorganic Breath"}, traitNames(overtunedTraits)...)}, // Toxoids
+}
+
+// latestVersion selects the first of catalogueVersions, whichever that is at the time.
+const latestVersion = "latest"
+
+// unversionedCatalogue is the version share codes and JSON output were made with before they recorded one.
+const unversionedCatalogue = "3.12"
+
+// catalogue holds every list that differs between versions. Generation, decoding and validation look
+// items up in the catalogue of the empire, see Empire.catalogue, never in the lists of main.go directly.
+type catalogue struct {
+	version     string
+	authorities []Authority
+	civics      []Civic
+	origins     []Origin
+	traits      []Trait
+	overtuned   []Trait
+	archetypes  []Archetype
+	popTypes    []string
+}
+
+// catalogues holds the catalogue of every version, filtered from the lists of main.go once. They are
+// never changed afterwards, so requests for different versions can use them side by side.
+var catalogues = buildCatalogues()
+
+// latestCatalogue is the catalogue of the first of catalogueVersions. The builder and the catalogue pages follow it.
+var latestCatalogue = catalogues[catalogueVersions[0].name]
+
+// resolveVersion turns an empty name or latestVersion into the name of the latest version.
+func resolveVersion(name string) (string, error) {
+	name = strings.TrimSpace(name)
+	if name == "" || name == latestVersion {
+		return catalogueVersions[0].name, nil
+	}
+	for _, version := range catalogueVersions {
+		if version.name == name {
+			return name, nil
+		}
+	}
+	return "", fmt.Errorf("unknown game version %q, use %s or %s", name, strings.Join(versionNames(), ", "), latestVersion)
+}
+
+func versionNames() []string {
+	res := []string{}
+	for _, version := range catalogueVersions {
+		res = append(res, version.name)
+	}
+	return res
+}
+
+// catalogueFor returns the catalogue of the given version, see resolveVersion.
+func catalogueFor(name string) (*catalogue, error) {
+	name, err := resolveVersion(name)
+	if err != nil {
+		return nil, err
+	}
+	return catalogues[name], nil
+}
+
+// catalogueOf is catalogueFor for versions that were checked before, the latest catalogue for an empty or unknown one.
+func catalogueOf(name string) *catalogue {
+	if c, ok := catalogues[name]; ok {
+		return c
+	}
+	return latestCatalogue
+}
+
+func buildCatalogues() map[string]*catalogue {
+	res := map[string]*catalogue{}
+	removed := []string{}
+	for _, version := range catalogueVersions {
+		removed = append(removed, version.without...)
+		res[version.name] = filterCatalogue(version.name, removed)
+	}
+	return res
+}
+
+// filterCatalogue builds a catalogue from the lists of main.go without the removed items.
+func filterCatalogue(version string, removed []string) *catalogue {
+	c := &catalogue{version: version}
+	for _, auth := range allAuthorities {
+		if !contains(removed, auth.name) {
+			c.authorities = append(c.authorities, auth)
+		}
+	}
+	for _, civic := range allCivics {
+		if !contains(removed, civic.name) {
+			c.civics = append(c.civics, civic)
+		}
+	}
+	for _, origin := range allOrigins {
+		if !contains(removed, origin.name) {
+			c.origins = append(c.origins, origin)
+		}
+	}
+	c.traits = withoutTraits(allTraits, removed)
+	c.overtuned = withoutTraits(overtunedTraits, removed)
+	for _, archetype := range allArchetypes {
+		archetype.popTypes = withoutNames(archetype.popTypes, removed)
+		c.archetypes = append(c.archetypes, archetype)
+	}
+	c.popTypes = withoutNames(allPopTypes, removed)
+	return c
+}
+
+func withoutTraits(traits []Trait, removed []string) []Trait {
+	res := []Trait{}
+	for _, trait := range traits {
+		if !contains(removed, trait.name) {
+			res = append(res, trait)
+		}
+	}
+	return res
+}
+
+func withoutNames(names []string, removed []string) []string {
+	res := []string{}
+	for _, name := range names {
+		if !contains(removed, name) {
+			res = append(res, name)
+		}
+	}
+	return res
+}
+
+// gameVersion is the catalogue version the empire was made with. Empires that do not record one,
+// such as those of the builder, follow the latest catalogue.
+func (e Empire) gameVersion() string {
+	if e.version != "" {
+		return e.version
+	}
+	return latestCatalogue.version
+}
+
+// catalogue is the catalogue of the version the empire was made with.
+func (e Empire) catalogue() *catalogue {
+	return catalogueOf(e.version)
+}
+
+// catalogue is the catalogue of the version the species was made with, see Species.version.
+func (s Species) catalogue() *catalogue {
+	return catalogueOf(s.version)
+}
+
+func (c *catalogue) civic(name string) Civic {
+	for _, civic := range c.civics {
+		if civic.name == name {
+			return civic
+		}
+	}
+	return Civic{name: name, isAllowed: always}
+}
+
+// civicVariant is civic for civics that come in several variants, such as Memorialists for machines
+// and for everyone else: it prefers the variant whose rules the rest of the empire meets.
+func (c *catalogue) civicVariant(others Empire, name string) Civic {
+	for _, civic := range c.civics {
+		if civic.name == name && civic.isAllowed.test(others) {
+			return civic
+		}
+	}
+	return c.civic(name)
+}
+
+func (c *catalogue) origin(name string) Origin {
+	for _, origin := range c.origins {
+		if origin.name == name {
+			return origin
+		}
+	}
+	return Origin{name: name, isAllowed: always}
+}
+
+// trait finds a trait the species of the catalogue may pick, or one of originTraits. Unknown traits are never allowed.
+func (c *catalogue) trait(name string) Trait {
+	for _, traits := range [][]Trait{c.traits, c.overtuned} {
+		for _, trait := range traits {
+			if trait.name == name {
+				return trait
+			}
+		}
+	}
+	if trait, ok := originTraits[name]; ok {
+		return trait
+	}
+	return Trait{name: name, isAllowed: never}
+}
+
+func (c *catalogue) hasPopType(popType string) bool {
+	for _, archetype := range c.archetypes {
+		if contains(archetype.popTypes, popType) {
+			return true
+		}
+	}
+	return false
+}
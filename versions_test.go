@@ -0,0 +1,62 @@
+package main
+
+import (
+	"encoding/json"
+	"strings"
+	"testing"
+)
+
+func TestFilterCatalogue(t *testing.T) {
+	c := filterCatalogue("test", []string{"Scavengers", "Toxoid"})
+	if c.version != "test" {
+		t.Errorf("version is %q", c.version)
+	}
+	if c.has("civic", "Scavengers") {
+		t.Error("removed civic Scavengers is still there")
+	}
+	if c.hasPopType("Toxoid") {
+		t.Error("removed pop type Toxoid is still there")
+	}
+	if !latestCatalogue.has("civic", "Scavengers") || !latestCatalogue.hasPopType("Toxoid") {
+		t.Error("filtering an older version changed the latest catalogue")
+	}
+}
+
+func TestDecodingLeavesCataloguesAlone(t *testing.T) {
+	empires, err := generateBatch([]string{""}, batchOptions{fanatic: defaultFanaticChance, version: "3.5"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	decoded, err := decodeShareCode(shareCode(empires[0]))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if decoded.version != "3.5" || decoded.mainSpecies.version != "3.5" {
+		t.Errorf("decoded empire follows %q and its species %q instead of 3.5", decoded.version, decoded.mainSpecies.version)
+	}
+	data, err := json.Marshal(empires[0])
+	if err != nil {
+		t.Fatal(err)
+	}
+	restored := Empire{}
+	if err := json.Unmarshal(data, &restored); err != nil {
+		t.Fatal(err)
+	}
+	if restored.version != "3.5" {
+		t.Errorf("restored empire follows %q instead of 3.5", restored.version)
+	}
+	if !latestCatalogue.hasPopType("Toxoid") || !(Empire{}).catalogue().has("origin", "Knights of the Toxic God") {
+		t.Error("decoding a 3.5 empire changed the latest catalogue")
+	}
+}
+
+func TestEmpireProblemFollowsVersion(t *testing.T) {
+	catalogues["test"] = filterCatalogue("test", []string{"Corporate"})
+	defer delete(catalogues, "test")
+	if problem := empireProblem(Empire{authority: "Corporate", version: "test"}); !strings.Contains(problem, "not in game version test") {
+		t.Errorf("Corporate in a version without it: got %q", problem)
+	}
+	if problem := empireProblem(Empire{authority: "Corporate"}); problem != "" {
+		t.Errorf("Corporate in the latest version: got %q", problem)
+	}
+}